@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"strings"
 	"time"
 
@@ -39,7 +40,7 @@ func executeHandler(c *gin.Context) {
 }
 
 func executeHTTPRequest(execReq ExecuteRequest) (*ExecuteResponse, error) {
-	startTime := time.Now()
+	timer := newRequestTimer()
 
 	// Create HTTP client with timeout and redirect limit
 	client := &http.Client{
@@ -72,6 +73,9 @@ func executeHTTPRequest(execReq ExecuteRequest) (*ExecuteResponse, error) {
 		req.Header.Set(key, value)
 	}
 
+	// Trace connection phases for the timing breakdown
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
@@ -86,8 +90,7 @@ func executeHTTPRequest(execReq ExecuteRequest) (*ExecuteResponse, error) {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	endTime := time.Now()
-	duration := endTime.Sub(startTime)
+	timing := timer.timing(time.Now())
 
 	// Extract response headers
 	responseHeaders := make(map[string][]string)
@@ -100,10 +103,6 @@ func executeHTTPRequest(execReq ExecuteRequest) (*ExecuteResponse, error) {
 		StatusText: resp.Status,
 		Headers:    responseHeaders,
 		Body:       string(responseBody),
-		Timing: TimingInfo{
-			StartTime: startTime,
-			EndTime:   endTime,
-			Duration:  duration.Milliseconds(),
-		},
+		Timing:     timing,
 	}, nil
 }
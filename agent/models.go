@@ -22,12 +22,21 @@ type ExecuteResponse struct {
 	Error      string              `json:"error,omitempty"`
 }
 
-// TimingInfo contains timing information about the request
+// TimingInfo contains timing information about the request. DNSLookup,
+// TCPConnection and TLSHandshake are whole milliseconds; the ...Ms fields and
+// the other phases are fractional milliseconds. Phases skipped, e.g. DNS, TCP
+// and TLS on a reused connection, are 0.
 type TimingInfo struct {
-	StartTime    time.Time `json:"startTime"`
-	EndTime      time.Time `json:"endTime"`
-	Duration     int64     `json:"duration"` // milliseconds
-	DNSLookup    int64     `json:"dnsLookup,omitempty"`
-	TCPConnection int64    `json:"tcpConnection,omitempty"`
-	TLSHandshake int64     `json:"tlsHandshake,omitempty"`
+	StartTime        time.Time `json:"startTime"`
+	EndTime          time.Time `json:"endTime"`
+	Duration         int64     `json:"duration"` // milliseconds
+	DNSLookup        int64     `json:"dnsLookup,omitempty"`
+	TCPConnection    int64     `json:"tcpConnection,omitempty"`
+	TLSHandshake     int64     `json:"tlsHandshake,omitempty"`
+	DNSLookupMs      float64   `json:"dnsLookupMs"`
+	TCPConnectionMs  float64   `json:"tcpConnectionMs"`
+	TLSHandshakeMs   float64   `json:"tlsHandshakeMs"`
+	TimeToFirstByte  float64   `json:"timeToFirstByte"`
+	ContentDownload  float64   `json:"contentDownload"`
+	ConnectionReused bool      `json:"connectionReused"`
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// requestTimer collects connection phase timestamps through httptrace. When
+// redirects are followed the phases describe the final hop.
+type requestTimer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func newRequestTimer() *requestTimer {
+	return &requestTimer{start: time.Now()}
+}
+
+func (t *requestTimer) trace() *httptrace.ClientTrace {
+	record := func(field *time.Time) {
+		t.mu.Lock()
+		*field = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// A new hop starts; forget the phases of the previous one
+			t.mu.Lock()
+			t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+			t.connectStart, t.connectDone = time.Time{}, time.Time{}
+			t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+			t.wroteRequest, t.firstByte = time.Time{}, time.Time{}
+			t.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { record(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { record(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			// Several addresses may be dialed in parallel; keep the first start
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone:       func(string, string, error) { record(&t.connectDone) },
+		TLSHandshakeStart: func() { record(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { record(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&t.wroteRequest) },
+		GotFirstResponseByte: func() { record(&t.firstByte) },
+	}
+}
+
+// timing builds the phase breakdown, with end marking when the body was fully read
+func (t *requestTimer) timing(end time.Time) TimingInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	dns := phase(t.dnsStart, t.dnsDone)
+	connect := phase(t.connectStart, t.connectDone)
+	handshake := phase(t.tlsStart, t.tlsDone)
+	return TimingInfo{
+		StartTime:        t.start,
+		EndTime:          end,
+		Duration:         end.Sub(t.start).Milliseconds(),
+		DNSLookup:        dns.Milliseconds(),
+		TCPConnection:    connect.Milliseconds(),
+		TLSHandshake:     handshake.Milliseconds(),
+		DNSLookupMs:      fractionalMs(dns),
+		TCPConnectionMs:  fractionalMs(connect),
+		TLSHandshakeMs:   fractionalMs(handshake),
+		TimeToFirstByte:  fractionalMs(phase(t.wroteRequest, t.firstByte)),
+		ContentDownload:  fractionalMs(phase(t.firstByte, end)),
+		ConnectionReused: t.reused,
+	}
+}
+
+// phase returns the duration between two timestamps, or 0 if the phase did
+// not happen
+func phase(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// fractionalMs returns d in milliseconds, to the microsecond
+func fractionalMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
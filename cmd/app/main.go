@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	_ "time/tzdata" // Monitor timezones must resolve on hosts without a zoneinfo database
 
 	"postman-runner/internal/config"
 	"postman-runner/internal/db"
@@ -29,17 +31,21 @@ func main() {
 
 	log.Println("✅ Connected to database successfully")
 
+	if err := handlers.EncryptCertificateKeys(database, cfg); err != nil {
+		log.Fatalf("Failed to encrypt certificate private keys: %v", err)
+	}
+
 	// Initialize Gin router
 	router := gin.New()
 
 	// Add middleware
 	router.Use(gin.Recovery()) // Panic recovery
-	// CORS middleware - allow localhost:5173 for development
+	// CORS middleware - allow the configured origins (localhost:5173 for development)
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
+		AllowOrigins:     cfg.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", handlers.ExecutionIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * 3600,
 	}))
@@ -53,6 +59,18 @@ func main() {
 	executionHandler := handlers.NewExecutionHandler(database, cfg)
 	itemHandler := handlers.NewItemHandler(database, cfg)
 	environmentHandler := handlers.NewEnvironmentHandler(database, cfg)
+	runHandler := handlers.NewRunHandler(database, cfg)
+	certificateHandler := handlers.NewCertificateHandler(database, cfg)
+	monitorHandler := handlers.NewMonitorHandler(database, cfg)
+
+	// Run scheduled monitors in the background
+	if cfg.MonitorsEnabled {
+		go handlers.NewMonitorScheduler(database, cfg).Run(context.Background())
+		log.Printf("Monitor scheduler started, polling every %v", cfg.MonitorPollInterval)
+	}
+
+	// Carry cancellations requested through other replicas to the executions running here
+	go handlers.NewCancellationWatcher(database, cfg).Run(context.Background())
 
 	// Health check endpoint (no rate limit)
 	router.GET("/health", handlers.HealthCheck)
@@ -65,17 +83,48 @@ func main() {
 	{
 		// Collections
 		api.POST("/collections/upload", collectionHandler.UploadCollection)
+		api.POST("/collections/upload/openapi", collectionHandler.ImportOpenAPI)
 		api.GET("/collections", collectionHandler.ListCollections)
 		api.GET("/collections/:id/tree", collectionHandler.GetCollectionTree)
+		api.PUT("/collections/:id/auth", collectionHandler.UpdateCollectionAuth)
 
 		// Items
 		api.POST("/collections/:id/items", itemHandler.CreateItem)
+		api.POST("/collections/:id/items/from-curl", itemHandler.ImportCurl)
 		api.GET("/items/:id", collectionHandler.GetItem)
 		api.PUT("/items/:id", itemHandler.UpdateItem)
 		api.DELETE("/items/:id", itemHandler.DeleteItem)
 
+		// GraphQL
+		api.POST("/items/:id/graphql/introspect", middleware.RateLimitMiddleware(limiter), executionHandler.IntrospectGraphQL)
+		api.GET("/collections/:id/graphql/schema", collectionHandler.GetGraphQLSchema)
+		api.GET("/items/:id/graphql/validate", itemHandler.ValidateGraphQL)
+
 		// Execution (with rate limiting)
 		api.POST("/items/:id/execute", middleware.RateLimitMiddleware(limiter), executionHandler.ExecuteRequest)
+		api.POST("/items/:id/execute/stream", middleware.RateLimitMiddleware(limiter), executionHandler.ExecuteStream)
+		api.GET("/items/:id/connect", middleware.RateLimitMiddleware(limiter), executionHandler.ConnectWebSocket)
+		api.POST("/items/:id/load-test", middleware.RateLimitMiddleware(limiter), executionHandler.LoadTest)
+		api.GET("/items/:id/snippet", executionHandler.GetSnippet)
+
+		// Execution history
+		api.GET("/items/:id/executions", executionHandler.ListItemExecutions)
+		api.GET("/executions/diff", executionHandler.DiffExecutions)
+		api.GET("/executions/:id", executionHandler.GetExecution)
+		api.DELETE("/executions/:id", executionHandler.CancelExecution)
+
+		// Runs (with rate limiting)
+		api.POST("/collections/:id/run", middleware.RateLimitMiddleware(limiter), runHandler.RunCollection)
+		api.POST("/items/:id/run", middleware.RateLimitMiddleware(limiter), runHandler.RunFolder)
+		api.GET("/runs/:id", runHandler.GetRun)
+
+		// Scheduled monitors
+		api.POST("/monitors", monitorHandler.CreateMonitor)
+		api.GET("/monitors", monitorHandler.ListMonitors)
+		api.GET("/monitors/:id", monitorHandler.GetMonitor)
+		api.PUT("/monitors/:id", monitorHandler.UpdateMonitor)
+		api.DELETE("/monitors/:id", monitorHandler.DeleteMonitor)
+		api.GET("/monitors/:id/results", monitorHandler.GetMonitorResults)
 
 		// Environments
 		api.POST("/environments", environmentHandler.CreateEnvironment)
@@ -84,6 +133,26 @@ func main() {
 		api.PUT("/environments/:id", environmentHandler.UpdateEnvironment)
 		api.PATCH("/environments/:id/variables", environmentHandler.BatchUpdateEnvironmentVariables)
 		api.DELETE("/environments/:id", environmentHandler.DeleteEnvironment)
+
+		// Environment cookie jars
+		api.GET("/environments/:id/cookies", environmentHandler.ListCookies)
+		api.POST("/environments/:id/cookies", environmentHandler.SetCookie)
+		api.PUT("/environments/:id/cookies/:cookieId", environmentHandler.UpdateCookie)
+		api.DELETE("/environments/:id/cookies/:cookieId", environmentHandler.DeleteCookie)
+		api.DELETE("/environments/:id/cookies", environmentHandler.ClearCookies)
+
+		// Outbound proxy
+		api.GET("/environments/:id/proxy", environmentHandler.GetProxyOptions)
+		api.PUT("/environments/:id/proxy", environmentHandler.UpdateProxyOptions)
+
+		// TLS verification and certificates
+		api.GET("/environments/:id/tls", environmentHandler.GetTLSOptions)
+		api.PUT("/environments/:id/tls", environmentHandler.UpdateTLSOptions)
+		api.POST("/certificates", certificateHandler.CreateCertificate)
+		api.GET("/certificates", certificateHandler.ListCertificates)
+		api.GET("/certificates/:id", certificateHandler.GetCertificate)
+		api.PUT("/certificates/:id", certificateHandler.UpdateCertificate)
+		api.DELETE("/certificates/:id", certificateHandler.DeleteCertificate)
 	}
 
 	// Start server
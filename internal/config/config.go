@@ -1,11 +1,15 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"postman-runner/internal/validator"
+
 	"github.com/joho/godotenv"
 )
 
@@ -21,6 +25,9 @@ type Config struct {
 	// Server
 	Port string
 
+	// Browser origins allowed by CORS and on WebSocket upgrades
+	AllowedOrigins []string
+
 	// Request Execution
 	RequestTimeout  time.Duration
 	MaxRequestSize  int64
@@ -28,6 +35,34 @@ type Config struct {
 	MaxHeaderCount  int
 	MaxRedirects    int
 
+	// Streamed executions
+	MaxStreamDuration time.Duration
+	MaxStreamEvents   int // 0 for no limit
+
+	// Load tests
+	LoadTestMaxConcurrency int
+	LoadTestMaxIterations  int
+	LoadTestMaxDuration    time.Duration
+
+	// Scheduled monitors
+	MonitorsEnabled          bool
+	MonitorPollInterval      time.Duration
+	MonitorMaxConcurrentRuns int
+
+	// Cancellation
+	CancelPollInterval time.Duration
+
+	// Outbound proxy, overridable per environment and per execution
+	ProxyURL string
+	NoProxy  string
+
+	// Execution History
+	MaxStoredResponseSize int64
+
+	// AES-256 key encrypting client certificate private keys at rest; nil
+	// stores them in plaintext
+	CertificateEncryptionKey []byte
+
 	// Rate Limiting
 	RateLimitRPS   int
 	RateLimitBurst int
@@ -58,6 +93,60 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT: %w", err)
 	}
 
+	cfg.MaxStreamDuration, err = time.ParseDuration(getEnv("MAX_STREAM_DURATION", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_STREAM_DURATION: %w", err)
+	}
+
+	cfg.MaxStreamEvents, err = strconv.Atoi(getEnv("MAX_STREAM_EVENTS", "100000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_STREAM_EVENTS: %w", err)
+	}
+	if cfg.MaxStreamEvents < 0 {
+		return nil, fmt.Errorf("invalid MAX_STREAM_EVENTS: must not be negative")
+	}
+
+	cfg.LoadTestMaxDuration, err = time.ParseDuration(getEnv("LOAD_TEST_MAX_DURATION", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOAD_TEST_MAX_DURATION: %w", err)
+	}
+
+	cfg.LoadTestMaxConcurrency, err = strconv.Atoi(getEnv("LOAD_TEST_MAX_CONCURRENCY", "50"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOAD_TEST_MAX_CONCURRENCY: %w", err)
+	}
+
+	cfg.LoadTestMaxIterations, err = strconv.Atoi(getEnv("LOAD_TEST_MAX_ITERATIONS", "100000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOAD_TEST_MAX_ITERATIONS: %w", err)
+	}
+
+	cfg.MonitorsEnabled = getEnv("MONITORS_ENABLED", "true") == "true"
+
+	cfg.MonitorPollInterval, err = time.ParseDuration(getEnv("MONITOR_POLL_INTERVAL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MONITOR_POLL_INTERVAL: %w", err)
+	}
+	if cfg.MonitorPollInterval <= 0 {
+		return nil, fmt.Errorf("invalid MONITOR_POLL_INTERVAL: must be positive")
+	}
+
+	cfg.CancelPollInterval, err = time.ParseDuration(getEnv("CANCEL_POLL_INTERVAL", "1s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CANCEL_POLL_INTERVAL: %w", err)
+	}
+	if cfg.CancelPollInterval <= 0 {
+		return nil, fmt.Errorf("invalid CANCEL_POLL_INTERVAL: must be positive")
+	}
+
+	cfg.MonitorMaxConcurrentRuns, err = strconv.Atoi(getEnv("MONITOR_MAX_CONCURRENT_RUNS", "4"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MONITOR_MAX_CONCURRENT_RUNS: %w", err)
+	}
+	if cfg.MonitorMaxConcurrentRuns < 1 {
+		return nil, fmt.Errorf("invalid MONITOR_MAX_CONCURRENT_RUNS: must be at least 1")
+	}
+
 	cfg.MaxRequestSize, err = strconv.ParseInt(getEnv("MAX_REQUEST_SIZE", "10485760"), 10, 64) // 10MB
 	if err != nil {
 		return nil, fmt.Errorf("invalid MAX_REQUEST_SIZE: %w", err)
@@ -68,6 +157,11 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid MAX_RESPONSE_SIZE: %w", err)
 	}
 
+	cfg.MaxStoredResponseSize, err = strconv.ParseInt(getEnv("MAX_STORED_RESPONSE_SIZE", "1048576"), 10, 64) // 1MB
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_STORED_RESPONSE_SIZE: %w", err)
+	}
+
 	cfg.MaxHeaderCount, err = strconv.Atoi(getEnv("MAX_HEADER_COUNT", "50"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid MAX_HEADER_COUNT: %w", err)
@@ -78,6 +172,30 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid MAX_REDIRECTS: %w", err)
 	}
 
+	if encoded := getEnv("CERTIFICATE_ENCRYPTION_KEY", ""); encoded != "" {
+		cfg.CertificateEncryptionKey, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CERTIFICATE_ENCRYPTION_KEY: %w", err)
+		}
+		if len(cfg.CertificateEncryptionKey) != 32 {
+			return nil, fmt.Errorf("invalid CERTIFICATE_ENCRYPTION_KEY: must be 32 bytes, base64-encoded")
+		}
+	}
+
+	cfg.ProxyURL = getEnv("OUTBOUND_PROXY_URL", "")
+	if cfg.ProxyURL != "" {
+		if _, err := validator.ParseProxyURL(cfg.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid OUTBOUND_PROXY_URL: %w", err)
+		}
+	}
+	cfg.NoProxy = getEnv("OUTBOUND_NO_PROXY", "")
+
+	for _, origin := range strings.Split(getEnv("ALLOWED_ORIGINS", "http://localhost:5173"), ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+
 	cfg.RateLimitRPS, err = strconv.Atoi(getEnv("RATE_LIMIT_RPS", "1000"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid RATE_LIMIT_RPS: %w", err)
@@ -99,6 +217,17 @@ func (c *Config) DatabaseURL() string {
 		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName, c.DBSSLMode)
 }
 
+// OriginAllowed reports whether a browser origin is in AllowedOrigins
+func (c *Config) OriginAllowed(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range c.AllowedOrigins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
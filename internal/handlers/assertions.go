@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/models"
+)
+
+// validateAssertions checks that every assertion is well-formed so that bad
+// definitions are rejected when saved rather than failing on every execution
+func validateAssertions(assertions []models.Assertion) error {
+	for i, a := range assertions {
+		var err error
+		switch a.Type {
+		case models.AssertStatusEquals, models.AssertResponseTimeBelow:
+			if _, ok := assertionNumber(a.Value); !ok {
+				err = fmt.Errorf("value must be a number")
+			}
+		case models.AssertStatusInRange:
+			if a.Min == nil || a.Max == nil || *a.Min > *a.Max {
+				err = fmt.Errorf("min and max are required and min must not exceed max")
+			}
+		case models.AssertHeaderExists:
+			if a.Header == "" {
+				err = fmt.Errorf("header is required")
+			}
+		case models.AssertHeaderEquals:
+			if a.Header == "" {
+				err = fmt.Errorf("header is required")
+			} else if _, ok := a.Value.(string); !ok {
+				err = fmt.Errorf("value must be a string")
+			}
+		case models.AssertJSONPathEquals, models.AssertJSONPathContains:
+			if _, perr := parseJSONPath(a.JSONPath); a.JSONPath == "" || perr != nil {
+				err = fmt.Errorf("json_path is missing or invalid")
+			}
+		case models.AssertJSONPathMatches:
+			if _, perr := parseJSONPath(a.JSONPath); a.JSONPath == "" || perr != nil {
+				err = fmt.Errorf("json_path is missing or invalid")
+			} else if pattern, ok := a.Value.(string); !ok {
+				err = fmt.Errorf("value must be a regular expression string")
+			} else if _, rerr := regexp.Compile(pattern); rerr != nil {
+				err = fmt.Errorf("invalid regular expression: %v", rerr)
+			}
+		case models.AssertJSONPathType:
+			if _, perr := parseJSONPath(a.JSONPath); a.JSONPath == "" || perr != nil {
+				err = fmt.Errorf("json_path is missing or invalid")
+			} else if !isJSONTypeName(a.Value) {
+				err = fmt.Errorf("value must be one of: string, number, boolean, object, array, null")
+			}
+		case models.AssertBodyContains:
+			if _, ok := a.Value.(string); !ok {
+				err = fmt.Errorf("value must be a string")
+			}
+		default:
+			err = fmt.Errorf("unknown assertion type '%s'", a.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("assertion %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// evaluateAssertions runs every enabled assertion against a response
+func evaluateAssertions(assertions []models.Assertion, response *models.ExecutionResponse) []models.AssertionResult {
+	var results []models.AssertionResult
+
+	// Bodies of other charsets are compared as UTF-8; binary ones fail every
+	// body assertion
+	body, bodyErr := responseText(response)
+
+	// Decode the body lazily, only if a JSONPath assertion needs it
+	var data interface{}
+	decodeErr := bodyErr
+	decoded := bodyErr != nil
+	jsonBody := func() (interface{}, error) {
+		if !decoded {
+			if err := json.Unmarshal([]byte(body), &data); err != nil {
+				decodeErr = errors.New("response body is not valid JSON")
+			}
+			decoded = true
+		}
+		return data, decodeErr
+	}
+
+	for _, a := range assertions {
+		if !a.Enabled {
+			continue
+		}
+
+		passed, message := evaluateAssertion(a, response, body, bodyErr, jsonBody)
+		results = append(results, models.AssertionResult{
+			Assertion: a,
+			Passed:    passed,
+			Message:   message,
+		})
+	}
+
+	return results
+}
+
+func evaluateAssertion(a models.Assertion, response *models.ExecutionResponse, body string, bodyErr error, jsonBody func() (interface{}, error)) (bool, string) {
+	switch a.Type {
+	case models.AssertStatusEquals:
+		expected, _ := assertionNumber(a.Value)
+		if float64(response.Status) != expected {
+			return false, fmt.Sprintf("expected status %v, got %d", expected, response.Status)
+		}
+		return true, ""
+
+	case models.AssertStatusInRange:
+		if a.Min == nil || a.Max == nil {
+			return false, "min and max are required"
+		}
+		if response.Status < *a.Min || response.Status > *a.Max {
+			return false, fmt.Sprintf("expected status between %d and %d, got %d", *a.Min, *a.Max, response.Status)
+		}
+		return true, ""
+
+	case models.AssertHeaderExists:
+		if _, ok := response.Headers[http.CanonicalHeaderKey(a.Header)]; !ok {
+			return false, fmt.Sprintf("header '%s' is missing", a.Header)
+		}
+		return true, ""
+
+	case models.AssertHeaderEquals:
+		values, ok := response.Headers[http.CanonicalHeaderKey(a.Header)]
+		if !ok {
+			return false, fmt.Sprintf("header '%s' is missing", a.Header)
+		}
+		// Repeated headers pass if any of their values matches
+		expected := fmt.Sprintf("%v", a.Value)
+		for _, actual := range values {
+			if actual == expected {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("expected header '%s' to be '%s', got '%s'", a.Header, expected, strings.Join(values, ", "))
+
+	case models.AssertBodyContains:
+		if bodyErr != nil {
+			return false, bodyErr.Error()
+		}
+		expected := fmt.Sprintf("%v", a.Value)
+		if !strings.Contains(body, expected) {
+			return false, fmt.Sprintf("body does not contain '%s'", expected)
+		}
+		return true, ""
+
+	case models.AssertResponseTimeBelow:
+		limit, _ := assertionNumber(a.Value)
+		if float64(response.DurationMs) >= limit {
+			return false, fmt.Sprintf("expected response time below %vms, took %dms", limit, response.DurationMs)
+		}
+		return true, ""
+
+	case models.AssertJSONPathEquals, models.AssertJSONPathContains, models.AssertJSONPathMatches, models.AssertJSONPathType:
+		data, err := jsonBody()
+		if err != nil {
+			return false, err.Error()
+		}
+		actual, err := evaluateJSONPath(data, a.JSONPath)
+		if err != nil {
+			return false, fmt.Sprintf("json_path '%s': %v", a.JSONPath, err)
+		}
+		return evaluateJSONAssertion(a, actual)
+	}
+
+	return false, fmt.Sprintf("unknown assertion type '%s'", a.Type)
+}
+
+func evaluateJSONAssertion(a models.Assertion, actual interface{}) (bool, string) {
+	switch a.Type {
+	case models.AssertJSONPathEquals:
+		if !jsonEqual(actual, a.Value) {
+			return false, fmt.Sprintf("expected %s to equal %s, got %s", a.JSONPath, jsonValueToString(a.Value), jsonValueToString(actual))
+		}
+		return true, ""
+
+	case models.AssertJSONPathContains:
+		switch v := actual.(type) {
+		case string:
+			if strings.Contains(v, fmt.Sprintf("%v", a.Value)) {
+				return true, ""
+			}
+		case []interface{}:
+			for _, element := range v {
+				if jsonEqual(element, a.Value) {
+					return true, ""
+				}
+			}
+		default:
+			return false, fmt.Sprintf("%s is neither a string nor an array", a.JSONPath)
+		}
+		return false, fmt.Sprintf("expected %s to contain %s", a.JSONPath, jsonValueToString(a.Value))
+
+	case models.AssertJSONPathMatches:
+		pattern, _ := a.Value.(string)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Sprintf("invalid regular expression: %v", err)
+		}
+		if !re.MatchString(jsonValueToString(actual)) {
+			return false, fmt.Sprintf("expected %s to match /%s/, got %s", a.JSONPath, pattern, jsonValueToString(actual))
+		}
+		return true, ""
+
+	case models.AssertJSONPathType:
+		actualType := jsonTypeName(actual)
+		if actualType != a.Value {
+			return false, fmt.Sprintf("expected %s to be of type %v, got %s", a.JSONPath, a.Value, actualType)
+		}
+		return true, ""
+	}
+
+	return false, fmt.Sprintf("unknown assertion type '%s'", a.Type)
+}
+
+// assertionNumber accepts numbers as well as numeric strings
+func assertionNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonEqual compares two decoded JSON values, normalizing numeric types
+func jsonEqual(a, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var out interface{}
+		if err := json.Unmarshal(encoded, &out); err != nil {
+			return v
+		}
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "unknown"
+}
+
+func isJSONTypeName(value interface{}) bool {
+	switch value {
+	case "string", "number", "boolean", "object", "array", "null":
+		return true
+	}
+	return false
+}
+
+// assertionsPassed reports whether every evaluated assertion passed, along
+// with a summary for failures
+func assertionsPassed(results []models.AssertionResult) (bool, string) {
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%d of %d assertions failed", failed, len(results))
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
+)
+
+// authRequest is the part of an outgoing request that auth schemes may change
+type authRequest struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// payload returns the body that will actually be sent, which is what signing
+// schemes have to cover
+func (r *authRequest) payload() string {
+	if !validator.MethodAllowsBody(r.Method) {
+		return ""
+	}
+	return r.Body
+}
+
+// convertPostmanAuth turns an imported Postman auth object into an Auth.
+// Returns nil when the object is missing or has no type.
+func convertPostmanAuth(postmanAuth models.PostmanAuth) *models.Auth {
+	authType, _ := postmanAuth["type"].(string)
+	if authType == "" {
+		return nil
+	}
+
+	auth := &models.Auth{Type: authType, Params: make(map[string]string)}
+	switch attributes := postmanAuth[authType].(type) {
+	case []interface{}:
+		// v2.1: [{"key": "token", "value": "...", "type": "string"}]
+		for _, attribute := range attributes {
+			pair, ok := attribute.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key, _ := pair["key"].(string)
+			if key == "" || pair["value"] == nil {
+				continue
+			}
+			auth.Params[key] = jsonValueToString(pair["value"])
+		}
+	case map[string]interface{}:
+		// v2.0: {"token": "..."}
+		for key, value := range attributes {
+			if value != nil {
+				auth.Params[key] = jsonValueToString(value)
+			}
+		}
+	}
+	if len(auth.Params) == 0 {
+		auth.Params = nil
+	}
+	return auth
+}
+
+// importPostmanAuth converts the auth of an imported collection, folder or
+// request. Auth of a type or grant the runner does not support is replaced
+// by noauth, so that it does not fail every request inheriting it, and
+// described in skipped with the location it was found at.
+func importPostmanAuth(postmanAuth models.PostmanAuth, location string, skipped *[]string) *models.Auth {
+	auth := convertPostmanAuth(postmanAuth)
+	if err := validateAuth(auth); err != nil {
+		if skipped != nil {
+			*skipped = append(*skipped, fmt.Sprintf("%s: %v", location, err))
+		}
+		return &models.Auth{Type: models.AuthTypeNoAuth}
+	}
+	return auth
+}
+
+// parseAuth decodes an auth JSONB column
+func parseAuth(data []byte) *models.Auth {
+	if len(data) == 0 {
+		return nil
+	}
+	var auth models.Auth
+	if err := json.Unmarshal(data, &auth); err != nil || auth.Type == "" {
+		return nil
+	}
+	return &auth
+}
+
+// encodeAuth serializes auth for an auth JSONB column
+func encodeAuth(auth *models.Auth) (interface{}, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(auth)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// validateAuth checks that an auth type is supported and carries the
+// attributes it needs
+func validateAuth(auth *models.Auth) error {
+	if auth == nil {
+		return nil
+	}
+
+	param := func(key string) string { return auth.Params[key] }
+	require := func(keys ...string) error {
+		var missing []string
+		for _, key := range keys {
+			if param(key) == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s auth requires %s", auth.Type, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+
+	switch auth.Type {
+	case models.AuthTypeNoAuth, models.AuthTypeInherit:
+		return nil
+	case models.AuthTypeBearer:
+		return require("token")
+	case models.AuthTypeBasic, models.AuthTypeDigest:
+		return require("username")
+	case models.AuthTypeAPIKey:
+		if in := param("in"); in != "" && in != "header" && in != "query" {
+			return fmt.Errorf("apikey auth 'in' must be 'header' or 'query'")
+		}
+		return require("key")
+	case models.AuthTypeOAuth2:
+		if param("accessToken") != "" && param("grant_type") != oauth2ClientCredentials {
+			return nil
+		}
+		if grantType := param("grant_type"); grantType != "" && grantType != oauth2ClientCredentials {
+			return fmt.Errorf("oauth2 auth only supports the client_credentials grant type")
+		}
+		return require("accessTokenUrl", "clientId")
+	case models.AuthTypeAWSV4:
+		return require("accessKey", "secretKey", "service")
+	case models.AuthTypeHMAC:
+		if _, ok := hmacAlgorithms[strings.ToLower(param("algorithm"))]; param("algorithm") != "" && !ok {
+			return fmt.Errorf("hmac auth algorithm must be one of: sha1, sha256, sha512")
+		}
+		if encoding := param("encoding"); encoding != "" && encoding != "hex" && encoding != "base64" {
+			return fmt.Errorf("hmac auth encoding must be 'hex' or 'base64'")
+		}
+		if payload := param("payload"); payload != "" && payload != "body" && payload != "canonical" {
+			return fmt.Errorf("hmac auth payload must be 'body' or 'canonical'")
+		}
+		return require("secret")
+	}
+	return fmt.Errorf("unsupported auth type '%s'", auth.Type)
+}
+
+// resolveItemAuth finds the auth that applies to an item the way Postman
+// does: the item's own auth, or else that of the nearest folder above it, or
+// else the collection's. Missing auth and "inherit" defer to the parent, while
+// "noauth" stops the search. Returns nil if no auth applies.
+func resolveItemAuth(db *sql.DB, item *models.CollectionItem) (*models.Auth, error) {
+	if item.Auth != nil && item.Auth.Type != models.AuthTypeInherit {
+		return effectiveAuth(item.Auth), nil
+	}
+
+	if item.ParentID.Valid {
+		rows, err := db.Query(`
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id, auth, 1 AS depth
+				FROM collection_items
+				WHERE id = $1
+
+				UNION ALL
+
+				SELECT ci.id, ci.parent_id, ci.auth, a.depth + 1
+				FROM collection_items ci
+				INNER JOIN ancestors a ON ci.id = a.parent_id
+			)
+			SELECT auth FROM ancestors ORDER BY depth
+		`, item.ParentID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var authJSON []byte
+			if err := rows.Scan(&authJSON); err != nil {
+				return nil, err
+			}
+			if auth := parseAuth(authJSON); auth != nil && auth.Type != models.AuthTypeInherit {
+				return effectiveAuth(auth), nil
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var authJSON []byte
+	err := db.QueryRow("SELECT auth FROM collections WHERE id = $1", item.CollectionID).Scan(&authJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if auth := parseAuth(authJSON); auth != nil && auth.Type != models.AuthTypeInherit {
+		return effectiveAuth(auth), nil
+	}
+	return nil, nil
+}
+
+func effectiveAuth(auth *models.Auth) *models.Auth {
+	if auth.Type == models.AuthTypeNoAuth {
+		return nil
+	}
+	return auth
+}
+
+// resolveAuthVariables returns a copy of auth with {{variable}} placeholders
+// resolved in every attribute
+func resolveAuthVariables(auth *models.Auth, resolver *variableResolver) *models.Auth {
+	if auth == nil {
+		return nil
+	}
+	resolved := &models.Auth{Type: auth.Type, Params: make(map[string]string, len(auth.Params))}
+	for key, value := range auth.Params {
+		resolved.Params[key] = resolver.Resolve(value)
+	}
+	return resolved
+}
+
+// applyAuth adds credentials to req. Digest auth needs a challenge from the
+// server first and is handled by digestAuthorization after the initial
+// response instead. Token requests are sent with opts.
+func (h *ExecutionHandler) applyAuth(ctx context.Context, auth *models.Auth, req *authRequest, opts requestOptions) *executionError {
+	if auth == nil {
+		return nil
+	}
+	if err := validateAuth(auth); err != nil {
+		return &executionError{Status: http.StatusBadRequest, Code: "invalid_auth", Message: err.Error()}
+	}
+
+	param := func(key string) string { return auth.Params[key] }
+
+	switch auth.Type {
+	case models.AuthTypeBearer:
+		setHeader(req.Headers, "Authorization", "Bearer "+param("token"))
+
+	case models.AuthTypeBasic:
+		setHeader(req.Headers, "Authorization", basicAuthorization(param("username"), param("password")))
+
+	case models.AuthTypeAPIKey:
+		if param("in") == "query" {
+			withKey, err := addQueryParam(req.URL, param("key"), param("value"))
+			if err != nil {
+				return &executionError{Status: http.StatusBadRequest, Code: "invalid_auth", Message: err.Error()}
+			}
+			req.URL = withKey
+		} else {
+			setHeader(req.Headers, param("key"), param("value"))
+		}
+
+	case models.AuthTypeOAuth2:
+		token, execErr := h.oauth2AccessToken(ctx, auth, opts)
+		if execErr != nil {
+			return execErr
+		}
+		if param("addTokenTo") == "queryParams" {
+			withToken, err := addQueryParam(req.URL, "access_token", token)
+			if err != nil {
+				return &executionError{Status: http.StatusBadRequest, Code: "invalid_auth", Message: err.Error()}
+			}
+			req.URL = withToken
+		} else {
+			prefix := param("headerPrefix")
+			if prefix == "" {
+				prefix = "Bearer"
+			}
+			setHeader(req.Headers, "Authorization", prefix+" "+token)
+		}
+
+	case models.AuthTypeAWSV4:
+		if err := signAWSV4(req, auth.Params); err != nil {
+			return &executionError{Status: http.StatusBadRequest, Code: "invalid_auth", Message: err.Error()}
+		}
+
+	case models.AuthTypeHMAC:
+		if err := signHMAC(req, auth.Params); err != nil {
+			return &executionError{Status: http.StatusBadRequest, Code: "invalid_auth", Message: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+func basicAuthorization(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// addQueryParam appends a query parameter to rawURL, keeping the existing
+// query string as it is
+func addQueryParam(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	param := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	if u.RawQuery == "" {
+		u.RawQuery = param
+	} else {
+		u.RawQuery += "&" + param
+	}
+	return u.String(), nil
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
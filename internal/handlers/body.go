@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strings"
+
+	"postman-runner/internal/models"
+)
+
+// isValidBodyMode reports whether mode is one of the supported Postman body modes
+func isValidBodyMode(mode string) bool {
+	switch mode {
+	case models.BodyModeRaw, models.BodyModeURLEncoded, models.BodyModeFormData, models.BodyModeFile, models.BodyModeGraphQL:
+		return true
+	}
+	return false
+}
+
+// splitPostmanBody converts an imported Postman body into the stored raw
+// body, body mode and structured body data
+func splitPostmanBody(body *models.PostmanBody) (string, string, *models.BodyData) {
+	if body == nil {
+		return "", models.BodyModeRaw, nil
+	}
+
+	switch body.Mode {
+	case models.BodyModeURLEncoded:
+		return "", body.Mode, &models.BodyData{URLEncoded: body.URLEncoded}
+	case models.BodyModeFormData:
+		return "", body.Mode, &models.BodyData{FormData: body.FormData}
+	case models.BodyModeFile:
+		return "", body.Mode, &models.BodyData{File: body.File}
+	case models.BodyModeGraphQL:
+		return "", body.Mode, &models.BodyData{GraphQL: body.GraphQL}
+	}
+
+	// Raw and unknown modes keep whatever raw text they carry
+	return body.Raw, models.BodyModeRaw, nil
+}
+
+// parseBodyData decodes the body_data JSONB column
+func parseBodyData(data []byte) *models.BodyData {
+	if len(data) == 0 {
+		return nil
+	}
+	var bodyData models.BodyData
+	if err := json.Unmarshal(data, &bodyData); err != nil {
+		return nil
+	}
+	return &bodyData
+}
+
+// encodeBodyData serializes body data for the body_data JSONB column
+func encodeBodyData(data *models.BodyData) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// buildRequestBody renders the payload for a body mode, resolving variables
+// in every text field. A matching Content-Type is added to headers unless
+// one is already set; multipart bodies always set their own boundary.
+func buildRequestBody(mode, raw string, data *models.BodyData, resolver *variableResolver, headers map[string]string) (string, error) {
+	if data == nil {
+		data = &models.BodyData{}
+	}
+
+	switch mode {
+	case models.BodyModeURLEncoded:
+		var pairs []string
+		for _, param := range data.URLEncoded {
+			if param.Disabled {
+				continue
+			}
+			pairs = append(pairs, url.QueryEscape(resolver.Resolve(param.Key))+"="+url.QueryEscape(resolver.Resolve(param.Value)))
+		}
+		setDefaultHeader(headers, "Content-Type", "application/x-www-form-urlencoded")
+		return strings.Join(pairs, "&"), nil
+
+	case models.BodyModeFormData:
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		for _, param := range data.FormData {
+			if param.Disabled {
+				continue
+			}
+			key := resolver.Resolve(param.Key)
+
+			if param.Type != "file" {
+				if err := writeFormField(writer, key, resolver.Resolve(param.Value), param.ContentType); err != nil {
+					return "", err
+				}
+				continue
+			}
+
+			content, err := decodeFileContent(param.Content)
+			if err != nil {
+				return "", fmt.Errorf("form-data file '%s': %w", key, err)
+			}
+			if err := writeFormFile(writer, key, fileName(param.Src), param.ContentType, content); err != nil {
+				return "", err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return "", fmt.Errorf("failed to build multipart body: %w", err)
+		}
+		setHeader(headers, "Content-Type", writer.FormDataContentType())
+		return buf.String(), nil
+
+	case models.BodyModeFile:
+		if data.File == nil {
+			return "", nil
+		}
+		content, err := decodeFileContent(data.File.Content)
+		if err != nil {
+			return "", fmt.Errorf("binary body: %w", err)
+		}
+		setDefaultHeader(headers, "Content-Type", "application/octet-stream")
+		return string(content), nil
+
+	case models.BodyModeGraphQL:
+		if data.GraphQL == nil {
+			return "", nil
+		}
+		payload, err := graphQLPayload(data.GraphQL, resolver)
+		if err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode GraphQL body: %w", err)
+		}
+		setDefaultHeader(headers, "Content-Type", "application/json")
+		return string(encoded), nil
+	}
+
+	return resolver.Resolve(raw), nil
+}
+
+// graphQLPayload resolves the parts of a GraphQL operation into the fields
+// of a GraphQL over HTTP request
+func graphQLPayload(graphQL *models.PostmanGraphQL, resolver *variableResolver) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"query": resolver.Resolve(graphQL.Query),
+	}
+	if variables := strings.TrimSpace(resolver.Resolve(graphQL.Variables)); variables != "" {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(variables)); err != nil {
+			return nil, fmt.Errorf("GraphQL variables are not valid JSON")
+		}
+		payload["variables"] = json.RawMessage(compacted.Bytes())
+	}
+	if operationName := resolver.Resolve(graphQL.OperationName); operationName != "" {
+		payload["operationName"] = operationName
+	}
+	return payload, nil
+}
+
+// graphQLQueryURL adds a GraphQL operation to the query string of urlStr,
+// which is how GraphQL over HTTP sends operations with GET
+func graphQLQueryURL(urlStr string, data *models.BodyData, resolver *variableResolver) (string, error) {
+	if data == nil || data.GraphQL == nil {
+		return urlStr, nil
+	}
+	payload, err := graphQLPayload(data.GraphQL, resolver)
+	if err != nil {
+		return "", err
+	}
+
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("query", payload["query"].(string))
+	if variables, ok := payload["variables"].(json.RawMessage); ok {
+		query.Set("variables", string(variables))
+	}
+	if operationName, ok := payload["operationName"].(string); ok {
+		query.Set("operationName", operationName)
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+func writeFormField(writer *multipart.Writer, key, value, contentType string) error {
+	if contentType == "" {
+		return writer.WriteField(key, value)
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(key)))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write([]byte(value))
+	return err
+}
+
+func writeFormFile(writer *multipart.Writer, key, filename, contentType string, content []byte) error {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(key), escapeQuotes(filename)))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// decodeFileContent decodes the base64 content attached to a file part.
+// Files imported from Postman only carry a local path, so they cannot be
+// sent until their content has been uploaded.
+func decodeFileContent(content string) ([]byte, error) {
+	if content == "" {
+		return nil, fmt.Errorf("file content has not been uploaded")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("file content is not valid base64: %w", err)
+	}
+	return decoded, nil
+}
+
+// fileName derives an upload file name from a Postman src, which may be a
+// path or a list of paths
+func fileName(src interface{}) string {
+	var p string
+	switch v := src.(type) {
+	case string:
+		p = v
+	case []interface{}:
+		if len(v) > 0 {
+			p, _ = v[0].(string)
+		}
+	}
+	p = strings.ReplaceAll(p, "\\", "/")
+	if name := path.Base(p); name != "." && name != "/" {
+		return name
+	}
+	return "file"
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// headerKey finds the key under which name is stored, ignoring case
+func headerKey(headers map[string]string, name string) (string, bool) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// setHeader sets a header, replacing any existing value regardless of case
+func setHeader(headers map[string]string, name, value string) {
+	if key, ok := headerKey(headers, name); ok {
+		delete(headers, key)
+	}
+	headers[name] = value
+}
+
+// setDefaultHeader sets a header only if it is not already present
+func setDefaultHeader(headers map[string]string, name, value string) {
+	if _, ok := headerKey(headers, name); !ok {
+		headers[name] = value
+	}
+}
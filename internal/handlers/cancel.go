@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"postman-runner/internal/config"
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ExecutionIDHeader carries the ID of the history record of a streamed
+// execution or WebSocket connection, under which DELETE /executions/:id
+// cancels it while in progress
+const ExecutionIDHeader = "X-Execution-ID"
+
+// statusClientClosedRequest is reported for cancelled executions, after the
+// nginx convention for requests abandoned before a response was sent
+const statusClientClosedRequest = 499
+
+// errExecutionCancelled is the cause of executions cancelled through the API
+var errExecutionCancelled = errors.New("execution cancelled")
+
+// cancelRegistry holds the executions or runs in progress on this replica,
+// by stored ID, with the function that cancels each
+type cancelRegistry struct {
+	mu      sync.Mutex
+	entries map[int]func()
+}
+
+var (
+	inflightExecutions = &cancelRegistry{entries: make(map[int]func())}
+	inflightRuns       = &cancelRegistry{entries: make(map[int]func())}
+)
+
+// add registers cancel under id. The returned function unregisters it.
+func (r *cancelRegistry) add(id int, cancel func()) func() {
+	r.mu.Lock()
+	r.entries[id] = cancel
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.entries, id)
+		r.mu.Unlock()
+	}
+}
+
+// cancel cancels id if it is in progress on this replica
+func (r *cancelRegistry) cancel(id int) {
+	r.mu.Lock()
+	cancel, ok := r.entries[id]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// ids returns the IDs in progress on this replica
+func (r *cancelRegistry) ids() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]int64, 0, len(r.entries))
+	for id := range r.entries {
+		ids = append(ids, int64(id))
+	}
+	return ids
+}
+
+// cancellable derives a context from parent that cancel ends as cancelled
+// through the API. release frees the context once the work is done.
+func cancellable(parent context.Context) (ctx context.Context, cancel func(), release func()) {
+	ctx, cancelCause := context.WithCancelCause(parent)
+	return ctx, func() { cancelCause(errExecutionCancelled) }, func() { cancelCause(nil) }
+}
+
+// startExecution stores the request of an execution about to be sent, so
+// that DELETE /executions/:id can cancel it by calling cancel. The returned
+// function must be called once the execution ends. If the request cannot be
+// stored the execution goes ahead, uncancellable, and is stored when it ends.
+func (h *ExecutionHandler) startExecution(record *models.Execution, cancel func()) func() {
+	if err := h.insertExecution(record); err != nil {
+		log.Printf("Failed to record start of execution of item %d: %v", *record.ItemID, err)
+		return func() {}
+	}
+	return inflightExecutions.add(record.ID, cancel)
+}
+
+// cancelledError describes an execution ended by ctx, or returns nil if ctx
+// is still live
+func cancelledError(ctx context.Context) *executionError {
+	if ctx.Err() == nil {
+		return nil
+	}
+	message := "Execution cancelled"
+	if !wasCancelled(ctx) {
+		message = "Execution cancelled: the caller went away"
+	}
+	return &executionError{Status: statusClientClosedRequest, Code: "cancelled", Message: message}
+}
+
+// wasCancelled reports whether ctx ended through DELETE /executions/:id
+func wasCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errExecutionCancelled)
+}
+
+// CancelExecution handles DELETE /executions/:id. An execution in progress,
+// listed without finished_at in the history, is cancelled; for an execution
+// of a collection run still in progress, the whole run is. Cancellation is
+// requested in the database, so it reaches the execution on whichever
+// replica runs it within the cancel poll interval.
+func (h *ExecutionHandler) CancelExecution(c *gin.Context) {
+	executionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Execution ID must be a valid integer",
+		})
+		return
+	}
+
+	var runID sql.NullInt64
+	err = h.db.QueryRow("SELECT run_id FROM executions WHERE id = $1", executionID).Scan(&runID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Execution not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch execution",
+		})
+		return
+	}
+
+	if runID.Valid {
+		result, err := h.db.Exec(`UPDATE runs SET cancel_requested = TRUE WHERE id = $1 AND status = 'running'`, runID.Int64)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to cancel run",
+			})
+			return
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			inflightRuns.cancel(int(runID.Int64))
+			c.JSON(http.StatusAccepted, gin.H{
+				"message":      "Run cancellation requested",
+				"execution_id": executionID,
+				"run_id":       runID.Int64,
+			})
+			return
+		}
+	}
+
+	result, err := h.db.Exec(`UPDATE executions SET cancel_requested = TRUE WHERE id = $1 AND finished_at IS NULL`, executionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to cancel execution",
+		})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "not_in_progress",
+			Message: "Execution is not in progress",
+		})
+		return
+	}
+	inflightExecutions.cancel(executionID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Execution cancellation requested",
+		"execution_id": executionID,
+	})
+}
+
+// CancellationWatcher cancels the executions and runs in progress on this
+// replica whose cancellation was requested through another one
+type CancellationWatcher struct {
+	db  *sql.DB
+	cfg *config.Config
+}
+
+func NewCancellationWatcher(db *sql.DB, cfg *config.Config) *CancellationWatcher {
+	return &CancellationWatcher{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Run polls for requested cancellations until ctx is done
+func (w *CancellationWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.CancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		w.cancelRequested("executions", inflightExecutions)
+		w.cancelRequested("runs", inflightRuns)
+	}
+}
+
+// cancelRequested cancels the entries of registry flagged for cancellation
+// in table
+func (w *CancellationWatcher) cancelRequested(table string, registry *cancelRegistry) {
+	ids := registry.ids()
+	if len(ids) == 0 {
+		return
+	}
+
+	rows, err := w.db.Query(`SELECT id FROM `+table+` WHERE cancel_requested AND id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		log.Printf("Failed to poll cancelled %s: %v", table, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Failed to scan cancelled %s: %v", table, err)
+			return
+		}
+		registry.cancel(id)
+	}
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/config"
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CertificateHandler struct {
+	db  *sql.DB
+	cfg *config.Config
+}
+
+func NewCertificateHandler(db *sql.DB, cfg *config.Config) *CertificateHandler {
+	return &CertificateHandler{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateCertificateRequest represents the request body for creating a certificate.
+// A client certificate and key must be given together; a CA bundle may be
+// given with them or on its own.
+type CreateCertificateRequest struct {
+	Name          string `json:"name" binding:"required"`
+	EnvironmentID *int   `json:"environment_id"`
+	HostPattern   string `json:"host_pattern" binding:"required"`
+	ClientCert    string `json:"client_cert"`
+	ClientKey     string `json:"client_key"`
+	CACert        string `json:"ca_cert"`
+}
+
+// UpdateCertificateRequest represents the request body for updating a certificate
+type UpdateCertificateRequest struct {
+	Name        *string `json:"name"`
+	HostPattern *string `json:"host_pattern"`
+	ClientCert  *string `json:"client_cert"`
+	ClientKey   *string `json:"client_key"`
+	CACert      *string `json:"ca_cert"`
+}
+
+// validateCertificateMaterial checks that the PEM data parses. Empty strings
+// mean the material is absent.
+func validateCertificateMaterial(clientCert, clientKey, caCert string) error {
+	if (clientCert == "") != (clientKey == "") {
+		return fmt.Errorf("client_cert and client_key must be provided together")
+	}
+	if clientCert == "" && caCert == "" {
+		return fmt.Errorf("a client certificate or a CA certificate is required")
+	}
+	if clientCert != "" {
+		if _, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey)); err != nil {
+			return fmt.Errorf("invalid client certificate or key: %v", err)
+		}
+	}
+	if caCert != "" {
+		if _, err := parseCertificatesPEM(caCert); err != nil {
+			return fmt.Errorf("invalid ca_cert: %v", err)
+		}
+	}
+	return nil
+}
+
+// encryptedKeyPrefix marks client keys stored encrypted with AES-256-GCM, as
+// the base64 of the nonce followed by the ciphertext
+const encryptedKeyPrefix = "enc:v1:"
+
+// sealClientKey encrypts a PEM private key for storage with key, or returns
+// it as is when no key is configured
+func sealClientKey(key []byte, pem string) (string, error) {
+	if key == nil || pem == "" {
+		return pem, nil
+	}
+	aead, err := newKeyCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(pem), nil)
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openClientKey decrypts a stored private key. Keys stored before encryption
+// was configured are returned as they are.
+func openClientKey(key []byte, stored string) (string, error) {
+	encoded, encrypted := strings.CutPrefix(stored, encryptedKeyPrefix)
+	if !encrypted {
+		return stored, nil
+	}
+	if key == nil {
+		return "", errors.New("private key is encrypted but CERTIFICATE_ENCRYPTION_KEY is not set")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted private key: %w", err)
+	}
+	aead, err := newKeyCipher(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted private key")
+	}
+	pem, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("private key cannot be decrypted with CERTIFICATE_ENCRYPTION_KEY")
+	}
+	return string(pem), nil
+}
+
+func newKeyCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptCertificateKeys encrypts the client keys stored in plaintext, once
+// an encryption key is configured
+func EncryptCertificateKeys(db *sql.DB, cfg *config.Config) error {
+	if cfg.CertificateEncryptionKey == nil {
+		var plaintext int
+		err := db.QueryRow(`SELECT COUNT(*) FROM certificates WHERE client_key NOT LIKE $1`, encryptedKeyPrefix+"%").Scan(&plaintext)
+		if err == nil && plaintext > 0 {
+			log.Printf("⚠️  %d client certificate private keys are stored in plaintext; set CERTIFICATE_ENCRYPTION_KEY to encrypt them", plaintext)
+		}
+		return err
+	}
+
+	rows, err := db.Query(`SELECT id, client_key FROM certificates WHERE client_key NOT LIKE $1`, encryptedKeyPrefix+"%")
+	if err != nil {
+		return err
+	}
+	keys := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var clientKey string
+		if err := rows.Scan(&id, &clientKey); err != nil {
+			rows.Close()
+			return err
+		}
+		keys[id] = clientKey
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, clientKey := range keys {
+		sealed, err := sealClientKey(cfg.CertificateEncryptionKey, clientKey)
+		if err != nil {
+			return err
+		}
+		// The key is only replaced if it was not changed meanwhile
+		if _, err := db.Exec(`UPDATE certificates SET client_key = $1 WHERE id = $2 AND client_key = $3`, sealed, id, clientKey); err != nil {
+			return err
+		}
+	}
+	if len(keys) > 0 {
+		log.Printf("Encrypted %d client certificate private keys", len(keys))
+	}
+	return nil
+}
+
+const certificateColumns = `id, name, environment_id, host_pattern, client_cert, client_key IS NOT NULL, ca_cert, created_at, updated_at`
+
+func scanCertificate(row rowScanner) (*models.Certificate, error) {
+	var cert models.Certificate
+	var environmentID sql.NullInt64
+	var clientCert, caCert sql.NullString
+	err := row.Scan(
+		&cert.ID,
+		&cert.Name,
+		&environmentID,
+		&cert.HostPattern,
+		&clientCert,
+		&cert.HasClientKey,
+		&caCert,
+		&cert.CreatedAt,
+		&cert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	cert.EnvironmentID = nullIntPtr(environmentID)
+	cert.ClientCert = clientCert.String
+	cert.CACert = caCert.String
+	return &cert, nil
+}
+
+// CreateCertificate handles POST /certificates
+func (h *CertificateHandler) CreateCertificate(c *gin.Context) {
+	var req CreateCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name and host_pattern are required",
+		})
+		return
+	}
+
+	if err := validateHostPattern(req.HostPattern); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := validateCertificateMaterial(req.ClientCert, req.ClientKey, req.CACert); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_certificate",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if req.EnvironmentID != nil {
+		var exists bool
+		err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM environments WHERE id = $1)", *req.EnvironmentID).Scan(&exists)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to check environment existence",
+			})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_environment",
+				Message: "Environment not found",
+			})
+			return
+		}
+	}
+
+	clientKey, err := sealClientKey(h.cfg.CertificateEncryptionKey, req.ClientKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "encryption_error",
+			Message: "Failed to encrypt the private key",
+		})
+		return
+	}
+
+	row := h.db.QueryRow(`
+		INSERT INTO certificates (name, environment_id, host_pattern, client_cert, client_key, ca_cert)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+certificateColumns,
+		req.Name, req.EnvironmentID, strings.ToLower(strings.TrimSpace(req.HostPattern)),
+		nullString(req.ClientCert), nullString(clientKey), nullString(req.CACert))
+	cert, err := scanCertificate(row)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create certificate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cert)
+}
+
+// ListCertificates handles GET /certificates. The optional environment_id
+// query parameter limits the list to the certificates used by that environment,
+// including global ones.
+func (h *CertificateHandler) ListCertificates(c *gin.Context) {
+	var environmentID *int
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		envID, err := strconv.Atoi(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_id",
+				Message: "environment_id must be a valid integer",
+			})
+			return
+		}
+		environmentID = &envID
+	}
+
+	query := `SELECT ` + certificateColumns + ` FROM certificates`
+	args := []interface{}{}
+	if environmentID != nil {
+		query += ` WHERE environment_id IS NULL OR environment_id = $1`
+		args = append(args, *environmentID)
+	}
+	query += ` ORDER BY environment_id NULLS LAST, id`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch certificates",
+		})
+		return
+	}
+	defer rows.Close()
+
+	certificates := []models.Certificate{}
+	for rows.Next() {
+		cert, err := scanCertificate(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan certificate",
+			})
+			return
+		}
+		certificates = append(certificates, *cert)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificates": certificates,
+	})
+}
+
+// GetCertificate handles GET /certificates/:id
+func (h *CertificateHandler) GetCertificate(c *gin.Context) {
+	certID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Certificate ID must be a valid integer",
+		})
+		return
+	}
+
+	cert, err := scanCertificate(h.db.QueryRow(`SELECT `+certificateColumns+` FROM certificates WHERE id = $1`, certID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Certificate not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch certificate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cert)
+}
+
+// UpdateCertificate handles PUT /certificates/:id. Empty strings remove the
+// client certificate pair or the CA bundle.
+func (h *CertificateHandler) UpdateCertificate(c *gin.Context) {
+	certID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Certificate ID must be a valid integer",
+		})
+		return
+	}
+
+	var req UpdateCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_json",
+			Message: "Invalid JSON format",
+		})
+		return
+	}
+
+	// Fetch the current material so the result can be validated as a whole
+	var name, hostPattern string
+	var clientCert, clientKey, caCert sql.NullString
+	err = h.db.QueryRow(`
+		SELECT name, host_pattern, client_cert, client_key, ca_cert
+		FROM certificates
+		WHERE id = $1
+	`, certID).Scan(&name, &hostPattern, &clientCert, &clientKey, &caCert)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Certificate not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch certificate",
+		})
+		return
+	}
+
+	if clientKey.Valid {
+		clientKey.String, err = openClientKey(h.cfg.CertificateEncryptionKey, clientKey.String)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "encryption_error",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.HostPattern != nil {
+		if err := validateHostPattern(*req.HostPattern); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: err.Error(),
+			})
+			return
+		}
+		hostPattern = strings.ToLower(strings.TrimSpace(*req.HostPattern))
+	}
+	if req.ClientCert != nil || req.ClientKey != nil {
+		if req.ClientCert == nil || req.ClientKey == nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_certificate",
+				Message: "client_cert and client_key must be updated together",
+			})
+			return
+		}
+		clientCert = sql.NullString{String: *req.ClientCert, Valid: *req.ClientCert != ""}
+		clientKey = sql.NullString{String: *req.ClientKey, Valid: *req.ClientKey != ""}
+	}
+	if req.CACert != nil {
+		caCert = sql.NullString{String: *req.CACert, Valid: *req.CACert != ""}
+	}
+
+	if err := validateCertificateMaterial(clientCert.String, clientKey.String, caCert.String); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_certificate",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	clientKey.String, err = sealClientKey(h.cfg.CertificateEncryptionKey, clientKey.String)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "encryption_error",
+			Message: "Failed to encrypt the private key",
+		})
+		return
+	}
+
+	row := h.db.QueryRow(`
+		UPDATE certificates
+		SET name = $1, host_pattern = $2, client_cert = $3, client_key = $4, ca_cert = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING `+certificateColumns,
+		name, hostPattern, clientCert, clientKey, caCert, certID)
+	cert, err := scanCertificate(row)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update certificate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cert)
+}
+
+// DeleteCertificate handles DELETE /certificates/:id
+func (h *CertificateHandler) DeleteCertificate(c *gin.Context) {
+	certID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Certificate ID must be a valid integer",
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`DELETE FROM certificates WHERE id = $1`, certID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete certificate",
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Certificate not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Certificate deleted successfully",
+	})
+}
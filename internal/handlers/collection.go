@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"postman-runner/internal/config"
 	"postman-runner/internal/models"
@@ -59,13 +60,23 @@ func (h *CollectionHandler) UploadCollection(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
+	var skippedAuth []string
+	collectionAuthJSON, err := encodeAuth(importPostmanAuth(collection.Auth, "collection", &skippedAuth))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Invalid collection auth",
+		})
+		return
+	}
+
 	// Insert collection
 	var collectionID int
 	err = tx.QueryRow(`
-		INSERT INTO collections (name, description)
-		VALUES ($1, $2)
+		INSERT INTO collections (name, description, auth)
+		VALUES ($1, $2, $3)
 		RETURNING id
-	`, collection.Info.Name, collection.Info.Description).Scan(&collectionID)
+	`, collection.Info.Name, collection.Info.Description, collectionAuthJSON).Scan(&collectionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
@@ -75,7 +86,7 @@ func (h *CollectionHandler) UploadCollection(c *gin.Context) {
 	}
 
 	// Recursively import items
-	if err := h.importItems(tx, collectionID, 0, collection.Item, 0); err != nil {
+	if err := h.importItems(tx, collectionID, 0, collection.Item, 0, &skippedAuth); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "import_error",
 			Message: fmt.Sprintf("Failed to import items: %v", err),
@@ -92,30 +103,108 @@ func (h *CollectionHandler) UploadCollection(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"collection_id": collectionID,
 		"message":       "Collection imported successfully",
+	}
+	if len(skippedAuth) > 0 {
+		// Requests affected are sent without auth until it is set up again
+		response["skipped_auth"] = skippedAuth
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// UpdateCollectionAuthRequest sets the auth inherited by every item of a
+// collection. A null auth removes it.
+type UpdateCollectionAuthRequest struct {
+	Auth *models.Auth `json:"auth"`
+}
+
+// UpdateCollectionAuth handles PUT /collections/:id/auth
+func (h *CollectionHandler) UpdateCollectionAuth(c *gin.Context) {
+	collectionIDStr := c.Param("id")
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Collection ID must be a valid integer",
+		})
+		return
+	}
+
+	var updateReq UpdateCollectionAuthRequest
+	if err := c.ShouldBindJSON(&updateReq); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_json",
+			Message: "Invalid JSON format",
+		})
+		return
+	}
+
+	if err := validateAuth(updateReq.Auth); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_auth",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	authJSON, err := encodeAuth(updateReq.Auth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_auth",
+			Message: "Failed to serialize auth",
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE collections SET auth = $1, updated_at = NOW()
+		WHERE id = $2
+	`, authJSON, collectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update collection auth",
+		})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Collection not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection auth updated successfully",
 	})
 }
 
-func (h *CollectionHandler) importItems(tx *sql.Tx, collectionID int, parentID int, items []models.PostmanItem, startOrder int) error {
+func (h *CollectionHandler) importItems(tx *sql.Tx, collectionID int, parentID int, items []models.PostmanItem, startOrder int, skippedAuth *[]string) error {
 	for i, item := range items {
 		sortOrder := startOrder + i
 
 		if len(item.Item) > 0 {
 			// It's a folder
+			authJSON, err := encodeAuth(importPostmanAuth(item.Auth, fmt.Sprintf("folder '%s'", item.Name), skippedAuth))
+			if err != nil {
+				return fmt.Errorf("failed to marshal auth: %w", err)
+			}
+
 			var folderID int
-			err := tx.QueryRow(`
-				INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order)
-				VALUES ($1, $2, $3, 'folder', $4)
+			err = tx.QueryRow(`
+				INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, auth)
+				VALUES ($1, $2, $3, 'folder', $4, $5)
 				RETURNING id
-			`, collectionID, nullInt(parentID), item.Name, sortOrder).Scan(&folderID)
+			`, collectionID, nullInt(parentID), item.Name, sortOrder, authJSON).Scan(&folderID)
 			if err != nil {
 				return fmt.Errorf("failed to insert folder: %w", err)
 			}
 
 			// Recursively import folder items
-			if err := h.importItems(tx, collectionID, folderID, item.Item, 0); err != nil {
+			if err := h.importItems(tx, collectionID, folderID, item.Item, 0, skippedAuth); err != nil {
 				return err
 			}
 		} else if item.Request != nil {
@@ -131,16 +220,23 @@ func (h *CollectionHandler) importItems(tx *sql.Tx, collectionID int, parentID i
 				return fmt.Errorf("failed to marshal headers: %w", err)
 			}
 
-			// Extract body
-			body := ""
-			if req.Body != nil && req.Body.Raw != "" {
-				body = req.Body.Raw
+			// Extract body, keeping structured data for non-raw body modes
+			body, bodyMode, bodyData := splitPostmanBody(req.Body)
+			bodyDataJSON, err := encodeBodyData(bodyData)
+			if err != nil {
+				return fmt.Errorf("failed to marshal body: %w", err)
+			}
+
+			authJSON, err := encodeAuth(importPostmanAuth(req.Auth, fmt.Sprintf("request '%s'", item.Name), skippedAuth))
+			if err != nil {
+				return fmt.Errorf("failed to marshal auth: %w", err)
 			}
 
 			_, err = tx.Exec(`
-				INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, method, url, headers, body)
-				VALUES ($1, $2, $3, 'request', $4, $5, $6, $7, $8)
-			`, collectionID, nullInt(parentID), item.Name, sortOrder, req.Method, urlStr, string(headersJSON), body)
+				INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, method, url, headers, body, body_mode, body_data, auth)
+				VALUES ($1, $2, $3, 'request', $4, $5, $6, $7, $8, $9, $10, $11)
+			`, collectionID, nullInt(parentID), item.Name, sortOrder, validator.NormalizeMethod(req.Method), urlStr, string(headersJSON),
+				body, bodyMode, bodyDataJSON, authJSON)
 			if err != nil {
 				return fmt.Errorf("failed to insert request: %w", err)
 			}
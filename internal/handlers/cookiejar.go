@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"postman-runner/internal/models"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+type cookieKey struct {
+	Domain, Path, Name string
+}
+
+// cookieJar is an http.CookieJar holding the cookies of one environment. It
+// follows RFC 6265 for domain, path, expiry and Secure handling. Cookies that
+// a response sets or removes are tracked so that save only writes back what
+// changed, leaving cookies touched by concurrent executions alone.
+type cookieJar struct {
+	mu            sync.Mutex
+	environmentID int
+	entries       map[cookieKey]*models.Cookie
+	dirty         map[cookieKey]bool
+}
+
+// loadCookieJar reads the unexpired cookies of an environment
+func loadCookieJar(db *sql.DB, environmentID int) (*cookieJar, error) {
+	rows, err := db.Query(`
+		SELECT id, environment_id, name, value, domain, path, expires, secure, http_only, host_only, created_at, updated_at
+		FROM environment_cookies
+		WHERE environment_id = $1 AND (expires IS NULL OR expires > NOW())
+	`, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jar := &cookieJar{
+		environmentID: environmentID,
+		entries:       make(map[cookieKey]*models.Cookie),
+		dirty:         make(map[cookieKey]bool),
+	}
+	for rows.Next() {
+		cookie, err := scanCookie(rows)
+		if err != nil {
+			return nil, err
+		}
+		jar.entries[cookieKey{cookie.Domain, cookie.Path, cookie.Name}] = cookie
+	}
+	return jar, rows.Err()
+}
+
+// SetCookies implements http.CookieJar
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		cookie, remove, ok := newJarCookie(u, c, now)
+		if !ok {
+			continue
+		}
+		key := cookieKey{cookie.Domain, cookie.Path, cookie.Name}
+
+		if remove {
+			if _, exists := j.entries[key]; exists {
+				delete(j.entries, key)
+				j.dirty[key] = true
+			}
+			continue
+		}
+
+		if existing, exists := j.entries[key]; exists {
+			cookie.CreatedAt = existing.CreatedAt
+		}
+		cookie.EnvironmentID = j.environmentID
+		j.entries[key] = cookie
+		j.dirty[key] = true
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := canonicalHost(u)
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	requestPath := u.EscapedPath()
+	if requestPath == "" {
+		requestPath = "/"
+	}
+
+	now := time.Now()
+	var matched []*models.Cookie
+	for _, cookie := range j.entries {
+		if cookie.Expires != nil && !cookie.Expires.After(now) {
+			continue
+		}
+		if cookie.Secure && !secure {
+			continue
+		}
+		if cookie.HostOnly && host != cookie.Domain || !cookie.HostOnly && !domainMatch(host, cookie.Domain) {
+			continue
+		}
+		if !pathMatch(requestPath, cookie.Path) {
+			continue
+		}
+		matched = append(matched, cookie)
+	}
+
+	// Longer paths first, then older cookies first (RFC 6265 section 5.4)
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].CreatedAt.Before(matched[b].CreatedAt)
+	})
+
+	cookies := make([]*http.Cookie, len(matched))
+	for i, cookie := range matched {
+		cookies[i] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+	}
+	return cookies
+}
+
+// save writes the cookies that changed since the jar was loaded
+func (j *cookieJar) save(db *sql.DB) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for key := range j.dirty {
+		cookie, exists := j.entries[key]
+		var err error
+		if exists {
+			_, err = db.Exec(`
+				INSERT INTO environment_cookies (environment_id, name, value, domain, path, expires, secure, http_only, host_only, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				ON CONFLICT (environment_id, domain, path, name) DO UPDATE SET
+					value = EXCLUDED.value,
+					expires = EXCLUDED.expires,
+					secure = EXCLUDED.secure,
+					http_only = EXCLUDED.http_only,
+					host_only = EXCLUDED.host_only,
+					updated_at = NOW()
+			`, j.environmentID, cookie.Name, cookie.Value, cookie.Domain, cookie.Path, cookie.Expires,
+				cookie.Secure, cookie.HTTPOnly, cookie.HostOnly, cookie.CreatedAt)
+		} else {
+			_, err = db.Exec(`
+				DELETE FROM environment_cookies
+				WHERE environment_id = $1 AND domain = $2 AND path = $3 AND name = $4
+			`, j.environmentID, key.Domain, key.Path, key.Name)
+		}
+		if err != nil {
+			return err
+		}
+		delete(j.dirty, key)
+	}
+	return nil
+}
+
+// newJarCookie applies the storage rules of RFC 6265 section 5.3 to a cookie
+// received from u. It reports whether the cookie deletes an existing one and
+// whether it may be stored at all.
+func newJarCookie(u *url.URL, c *http.Cookie, now time.Time) (*models.Cookie, bool, bool) {
+	host := canonicalHost(u)
+	if c.Name == "" || host == "" {
+		return nil, false, false
+	}
+
+	// Secure cookies can only be set over a secure connection
+	if c.Secure && u.Scheme != "https" && u.Scheme != "wss" {
+		return nil, false, false
+	}
+
+	cookie := &models.Cookie{
+		Name:      c.Name,
+		Value:     c.Value,
+		Secure:    c.Secure,
+		HTTPOnly:  c.HttpOnly,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	switch {
+	case domain == "" || domain == host:
+		cookie.Domain = host
+		cookie.HostOnly = domain == ""
+	case net.ParseIP(host) != nil:
+		// IP addresses never match a different domain
+		return nil, false, false
+	case !domainMatch(host, domain):
+		return nil, false, false
+	default:
+		// Refuse cookies for a public suffix such as "com" or "co.uk"
+		if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+			return nil, false, false
+		}
+		cookie.Domain = domain
+	}
+
+	cookie.Path = c.Path
+	if cookie.Path == "" || cookie.Path[0] != '/' {
+		cookie.Path = defaultCookiePath(u.EscapedPath())
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		return cookie, true, true
+	case c.MaxAge > 0:
+		expires := now.Add(time.Duration(c.MaxAge) * time.Second).UTC()
+		cookie.Expires = &expires
+	case !c.Expires.IsZero():
+		if !c.Expires.After(now) {
+			return cookie, true, true
+		}
+		expires := c.Expires.UTC()
+		cookie.Expires = &expires
+	}
+
+	return cookie, false, true
+}
+
+// canonicalHost returns the lower-cased host of u without its port
+func canonicalHost(u *url.URL) string {
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
+
+// domainMatch reports whether host is domain or one of its subdomains
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch implements the path-match rule of RFC 6265 section 5.1.4
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath is the directory of the request path (RFC 6265 section 5.1.4)
+func defaultCookiePath(requestPath string) string {
+	if requestPath == "" || requestPath[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(requestPath, "/")
+	if i == 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
+
+func scanCookie(row rowScanner) (*models.Cookie, error) {
+	var cookie models.Cookie
+	var expires sql.NullTime
+	err := row.Scan(
+		&cookie.ID,
+		&cookie.EnvironmentID,
+		&cookie.Name,
+		&cookie.Value,
+		&cookie.Domain,
+		&cookie.Path,
+		&expires,
+		&cookie.Secure,
+		&cookie.HTTPOnly,
+		&cookie.HostOnly,
+		&cookie.CreatedAt,
+		&cookie.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if expires.Valid {
+		cookie.Expires = &expires.Time
+	}
+	return &cookie, nil
+}
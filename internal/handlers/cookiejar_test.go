@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"postman-runner/internal/models"
+)
+
+func newTestCookieJar() *cookieJar {
+	return &cookieJar{
+		entries: make(map[cookieKey]*models.Cookie),
+		dirty:   make(map[cookieKey]bool),
+	}
+}
+
+// setCookies stores the Set-Cookie header values of a response from rawURL
+func setCookies(t *testing.T, jar *cookieJar, rawURL string, values ...string) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := &http.Response{Header: http.Header{"Set-Cookie": values}}
+	jar.SetCookies(u, response.Cookies())
+}
+
+// cookieNames returns the name=value pairs the jar sends to rawURL, sorted
+func cookieNames(t *testing.T, jar *cookieJar, rawURL string) []string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, cookie := range jar.Cookies(u) {
+		names = append(names, cookie.Name+"="+cookie.Value)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCookieJar(t *testing.T) {
+	tests := []struct {
+		name    string
+		fromURL string
+		set     []string
+		toURL   string
+		want    []string
+	}{
+		{
+			name:    "host-only cookie sent to its host",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1"},
+			toURL:   "https://example.com/",
+			want:    []string{"a=1"},
+		},
+		{
+			name:    "host-only cookie not sent to subdomains",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1"},
+			toURL:   "https://api.example.com/",
+		},
+		{
+			name:    "domain cookie sent to subdomains",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Domain=.example.com"},
+			toURL:   "https://api.example.com/",
+			want:    []string{"a=1"},
+		},
+		{
+			name:    "domain cookie not sent to lookalike domains",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Domain=example.com"},
+			toURL:   "https://badexample.com/",
+		},
+		{
+			name:    "cookie for another domain refused",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Domain=other.com"},
+			toURL:   "https://other.com/",
+		},
+		{
+			name:    "cookie for a public suffix refused",
+			fromURL: "https://example.co.uk/",
+			set:     []string{"a=1; Domain=co.uk"},
+			toURL:   "https://other.co.uk/",
+		},
+		{
+			name:    "path prefix matched on a segment boundary",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Path=/api", "b=2; Path=/api/v1", "c=3; Path=/ap"},
+			toURL:   "https://example.com/api/users",
+			want:    []string{"a=1"},
+		},
+		{
+			name:    "default path is the directory of the request",
+			fromURL: "https://example.com/api/login",
+			set:     []string{"a=1"},
+			toURL:   "https://example.com/other",
+		},
+		{
+			name:    "Secure cookie not set over http",
+			fromURL: "http://example.com/",
+			set:     []string{"a=1; Secure", "b=2"},
+			toURL:   "https://example.com/",
+			want:    []string{"b=2"},
+		},
+		{
+			name:    "Secure cookie not sent over http",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Secure", "b=2"},
+			toURL:   "http://example.com/",
+			want:    []string{"b=2"},
+		},
+		{
+			name:    "Secure cookie sent over wss",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1; Secure"},
+			toURL:   "wss://example.com/",
+			want:    []string{"a=1"},
+		},
+		{
+			name:    "Max-Age=0 deletes",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1", "b=2", "a=; Max-Age=0"},
+			toURL:   "https://example.com/",
+			want:    []string{"b=2"},
+		},
+		{
+			name:    "past Expires deletes",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1", "a=1; Expires=Thu, 01 Jan 1970 00:00:00 GMT"},
+			toURL:   "https://example.com/",
+		},
+		{
+			name:    "later cookie replaces earlier",
+			fromURL: "https://example.com/",
+			set:     []string{"a=1", "a=2"},
+			toURL:   "https://example.com/",
+			want:    []string{"a=2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jar := newTestCookieJar()
+			setCookies(t, jar, tt.fromURL, tt.set...)
+			if got := cookieNames(t, jar, tt.toURL); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cookies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieJarDeletionIsSaved(t *testing.T) {
+	// A stored cookie deleted by Max-Age=0 must be written back as a delete
+	jar := newTestCookieJar()
+	key := cookieKey{Domain: "example.com", Path: "/", Name: "session"}
+	jar.entries[key] = &models.Cookie{Name: "session", Value: "x", Domain: "example.com", Path: "/", HostOnly: true}
+
+	setCookies(t, jar, "https://example.com/", "session=; Max-Age=0")
+	if _, exists := jar.entries[key]; exists {
+		t.Errorf("cookie still in the jar")
+	}
+	if !jar.dirty[key] {
+		t.Errorf("deletion not marked for saving")
+	}
+
+	// Deleting a cookie the jar does not hold changes nothing
+	setCookies(t, jar, "https://example.com/", "other=; Max-Age=0")
+	if len(jar.dirty) != 1 {
+		t.Errorf("dirty = %v, want only %v", jar.dirty, key)
+	}
+}
+
+func TestDomainMatch(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"example.com", "example.com", true},
+		{"api.example.com", "example.com", true},
+		{"a.b.example.com", "example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com", "api.example.com", false},
+		{"10.0.0.1", "0.0.1", false},
+		{"10.0.0.1", "10.0.0.1", true},
+	}
+	for _, tt := range tests {
+		if got := domainMatch(tt.host, tt.domain); got != tt.want {
+			t.Errorf("domainMatch(%q, %q) = %v, want %v", tt.host, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestPathMatch(t *testing.T) {
+	tests := []struct {
+		requestPath, cookiePath string
+		want                    bool
+	}{
+		{"/", "/", true},
+		{"/api", "/", true},
+		{"/api", "/api", true},
+		{"/api/users", "/api", true},
+		{"/api/users", "/api/", true},
+		{"/apis", "/api", false},
+		{"/api", "/api/", false},
+		{"/", "/api", false},
+	}
+	for _, tt := range tests {
+		if got := pathMatch(tt.requestPath, tt.cookiePath); got != tt.want {
+			t.Errorf("pathMatch(%q, %q) = %v, want %v", tt.requestPath, tt.cookiePath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// CookieRequest represents the request body for creating or editing a cookie
+type CookieRequest struct {
+	Name     string     `json:"name" binding:"required"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain" binding:"required"`
+	Path     string     `json:"path"`
+	Expires  *time.Time `json:"expires"`
+	Secure   bool       `json:"secure"`
+	HTTPOnly bool       `json:"http_only"`
+	HostOnly bool       `json:"host_only"`
+}
+
+// normalize validates the cookie and fills in defaults
+func (r *CookieRequest) normalize() string {
+	r.Domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(r.Domain), "."))
+	if r.Domain == "" {
+		return "domain is required"
+	}
+	if r.Path == "" {
+		r.Path = "/"
+	}
+	if r.Path[0] != '/' {
+		return "path must start with '/'"
+	}
+	if strings.ContainsAny(r.Name, "=;, \t\r\n") {
+		return "name contains invalid characters"
+	}
+	if strings.ContainsAny(r.Value, ";\r\n") {
+		return "value contains invalid characters"
+	}
+	if r.Expires != nil {
+		expires := r.Expires.UTC()
+		r.Expires = &expires
+	}
+	return ""
+}
+
+// parseEnvironmentID reads the environment ID and checks that the
+// environment exists, writing an error response if not
+func (h *EnvironmentHandler) parseEnvironmentID(c *gin.Context) (int, bool) {
+	envID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Environment ID must be a valid integer",
+		})
+		return 0, false
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM environments WHERE id = $1)", envID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check environment existence",
+		})
+		return 0, false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Environment not found",
+		})
+		return 0, false
+	}
+
+	return envID, true
+}
+
+// ListCookies handles GET /environments/:id/cookies. The optional domain
+// query parameter limits the list to a domain and its subdomains.
+func (h *EnvironmentHandler) ListCookies(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(c.Query("domain"), "."))
+	rows, err := h.db.Query(`
+		SELECT id, environment_id, name, value, domain, path, expires, secure, http_only, host_only, created_at, updated_at
+		FROM environment_cookies
+		WHERE environment_id = $1 AND (expires IS NULL OR expires > NOW())
+			AND ($2 = '' OR domain = $2 OR right(domain, length($2) + 1) = '.' || $2)
+		ORDER BY domain, path, name
+	`, envID, domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch cookies",
+		})
+		return
+	}
+	defer rows.Close()
+
+	cookies := []models.Cookie{}
+	for rows.Next() {
+		cookie, err := scanCookie(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan cookie",
+			})
+			return
+		}
+		cookies = append(cookies, *cookie)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cookies": cookies,
+	})
+}
+
+// SetCookie handles POST /environments/:id/cookies. A cookie with the same
+// domain, path and name is replaced.
+func (h *EnvironmentHandler) SetCookie(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	var req CookieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name and domain are required",
+		})
+		return
+	}
+	if msg := req.normalize(); msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: msg,
+		})
+		return
+	}
+
+	row := h.db.QueryRow(`
+		INSERT INTO environment_cookies (environment_id, name, value, domain, path, expires, secure, http_only, host_only)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (environment_id, domain, path, name) DO UPDATE SET
+			value = EXCLUDED.value,
+			expires = EXCLUDED.expires,
+			secure = EXCLUDED.secure,
+			http_only = EXCLUDED.http_only,
+			host_only = EXCLUDED.host_only,
+			updated_at = NOW()
+		RETURNING id, environment_id, name, value, domain, path, expires, secure, http_only, host_only, created_at, updated_at
+	`, envID, req.Name, req.Value, req.Domain, req.Path, req.Expires, req.Secure, req.HTTPOnly, req.HostOnly)
+	cookie, err := scanCookie(row)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to save cookie",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cookie)
+}
+
+// UpdateCookie handles PUT /environments/:id/cookies/:cookieId
+func (h *EnvironmentHandler) UpdateCookie(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	cookieID, err := strconv.Atoi(c.Param("cookieId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Cookie ID must be a valid integer",
+		})
+		return
+	}
+
+	var req CookieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name and domain are required",
+		})
+		return
+	}
+	if msg := req.normalize(); msg != "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: msg,
+		})
+		return
+	}
+
+	row := h.db.QueryRow(`
+		UPDATE environment_cookies
+		SET name = $1, value = $2, domain = $3, path = $4, expires = $5,
+			secure = $6, http_only = $7, host_only = $8, updated_at = NOW()
+		WHERE id = $9 AND environment_id = $10
+		RETURNING id, environment_id, name, value, domain, path, expires, secure, http_only, host_only, created_at, updated_at
+	`, req.Name, req.Value, req.Domain, req.Path, req.Expires, req.Secure, req.HTTPOnly, req.HostOnly, cookieID, envID)
+	cookie, err := scanCookie(row)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Cookie not found",
+		})
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "conflict",
+			Message: "A cookie with the same name, domain and path already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update cookie",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cookie)
+}
+
+// DeleteCookie handles DELETE /environments/:id/cookies/:cookieId
+func (h *EnvironmentHandler) DeleteCookie(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	cookieID, err := strconv.Atoi(c.Param("cookieId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Cookie ID must be a valid integer",
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`DELETE FROM environment_cookies WHERE id = $1 AND environment_id = $2`, cookieID, envID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete cookie",
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Cookie not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cookie deleted successfully",
+	})
+}
+
+// ClearCookies handles DELETE /environments/:id/cookies. With a domain query
+// parameter only the cookies of that domain and its subdomains are removed.
+func (h *EnvironmentHandler) ClearCookies(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(c.Query("domain"), "."))
+	result, err := h.db.Exec(`
+		DELETE FROM environment_cookies
+		WHERE environment_id = $1 AND ($2 = '' OR domain = $2 OR right(domain, length($2) + 1) = '.' || $2)
+	`, envID, domain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to clear cookies",
+		})
+		return
+	}
+
+	deleted, _ := result.RowsAffected()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cookies cleared successfully",
+		"deleted": deleted,
+	})
+}
@@ -0,0 +1,743 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportCurlRequest is the body of POST /collections/:id/items/from-curl
+type ImportCurlRequest struct {
+	Command  string `json:"command" binding:"required"`
+	Name     string `json:"name,omitempty"` // Defaults to the method and path of the URL
+	ParentID *int   `json:"parent_id,omitempty"`
+}
+
+// ImportCurl handles POST /collections/:id/items/from-curl. The pasted curl
+// command line is turned into a request item, which is validated and stored
+// like one created through CreateItem. Flags the item cannot reproduce are
+// listed in the response rather than dropped silently.
+func (h *ItemHandler) ImportCurl(c *gin.Context) {
+	collectionID, ok := h.requireCollection(c)
+	if !ok {
+		return
+	}
+
+	var importReq ImportCurlRequest
+	if err := c.ShouldBindJSON(&importReq); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "command is required",
+		})
+		return
+	}
+
+	command, err := parseCurlCommand(importReq.Command)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_curl_command",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	createReq := command.item
+	createReq.Name = importReq.Name
+	if createReq.Name == "" {
+		createReq.Name = curlItemName(createReq.Method, createReq.URL)
+	}
+	createReq.ParentID = importReq.ParentID
+
+	newItem, ok := h.createItem(c, collectionID, createReq)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CurlImport{
+		Item:             newItem,
+		UnsupportedFlags: command.unsupported,
+	})
+}
+
+// curlItemName names an imported item after its method and URL path
+func curlItemName(method, rawURL string) string {
+	name := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		name = parsed.Path
+		if name == "" {
+			name = "/"
+		}
+	}
+	return truncateItemName(strings.TrimSpace(method + " " + name))
+}
+
+// curlShortFlags maps the short flags of curl to their long names
+var curlShortFlags = map[byte]string{
+	'#': "progress-bar",
+	'0': "http1.0",
+	'1': "tlsv1",
+	'2': "sslv2",
+	'3': "sslv3",
+	'4': "ipv4",
+	'6': "ipv6",
+	':': "next",
+	'a': "append",
+	'A': "user-agent",
+	'b': "cookie",
+	'B': "use-ascii",
+	'c': "cookie-jar",
+	'C': "continue-at",
+	'd': "data",
+	'D': "dump-header",
+	'e': "referer",
+	'E': "cert",
+	'f': "fail",
+	'F': "form",
+	'g': "globoff",
+	'G': "get",
+	'h': "help",
+	'H': "header",
+	'i': "include",
+	'I': "head",
+	'j': "junk-session-cookies",
+	'J': "remote-header-name",
+	'k': "insecure",
+	'K': "config",
+	'l': "list-only",
+	'L': "location",
+	'm': "max-time",
+	'M': "manual",
+	'n': "netrc",
+	'N': "no-buffer",
+	'o': "output",
+	'O': "remote-name",
+	'p': "proxytunnel",
+	'P': "ftp-port",
+	'q': "disable",
+	'Q': "quote",
+	'r': "range",
+	'R': "remote-time",
+	's': "silent",
+	'S': "show-error",
+	't': "telnet-option",
+	'T': "upload-file",
+	'u': "user",
+	'U': "proxy-user",
+	'v': "verbose",
+	'V': "version",
+	'w': "write-out",
+	'x': "proxy",
+	'X': "request",
+	'y': "speed-time",
+	'Y': "speed-limit",
+	'z': "time-cond",
+	'Z': "parallel",
+}
+
+// curlArgumentFlags lists the long flags that take an argument, including
+// unsupported ones so that their arguments are not mistaken for URLs
+var curlArgumentFlags = map[string]bool{
+	"abstract-unix-socket": true, "alt-svc": true, "aws-sigv4": true, "cacert": true,
+	"capath": true, "cert": true, "cert-type": true, "ciphers": true, "config": true,
+	"connect-timeout": true, "connect-to": true, "continue-at": true, "cookie": true,
+	"cookie-jar": true, "create-file-mode": true, "crlfile": true, "curves": true,
+	"data": true, "data-ascii": true, "data-binary": true, "data-raw": true,
+	"data-urlencode": true, "delegation": true, "dns-interface": true,
+	"dns-ipv4-addr": true, "dns-ipv6-addr": true, "dns-servers": true, "doh-url": true,
+	"dump-header": true, "ech": true, "egd-file": true, "engine": true,
+	"etag-compare": true, "etag-save": true, "expect100-timeout": true, "form": true,
+	"form-string": true, "ftp-account": true, "ftp-alternative-to-user": true,
+	"ftp-method": true, "ftp-port": true, "ftp-ssl-ccc-mode": true,
+	"happy-eyeballs-timeout-ms": true, "haproxy-clientip": true, "header": true,
+	"hostpubmd5": true, "hostpubsha256": true, "hsts": true, "interface": true,
+	"ip-tos": true, "ipfs-gateway": true, "json": true, "keepalive-cnt": true,
+	"keepalive-time": true, "key": true, "key-type": true, "krb": true, "libcurl": true,
+	"limit-rate": true, "local-port": true, "login-options": true, "mail-auth": true,
+	"mail-from": true, "mail-rcpt": true, "max-filesize": true, "max-redirs": true,
+	"max-time": true, "netrc-file": true, "noproxy": true, "oauth2-bearer": true,
+	"output": true, "output-dir": true, "parallel-max": true, "pass": true,
+	"pinnedpubkey": true, "preproxy": true, "proto": true, "proto-default": true,
+	"proto-redir": true, "proxy": true, "proxy-cacert": true, "proxy-capath": true,
+	"proxy-cert": true, "proxy-cert-type": true, "proxy-ciphers": true,
+	"proxy-crlfile": true, "proxy-header": true, "proxy-key": true, "proxy-key-type": true,
+	"proxy-pass": true, "proxy-pinnedpubkey": true, "proxy-service-name": true,
+	"proxy-tls13-ciphers": true, "proxy-tlsauthtype": true, "proxy-tlspassword": true,
+	"proxy-tlsuser": true, "proxy-user": true, "proxy1.0": true, "pubkey": true,
+	"quote": true, "random-file": true, "range": true, "rate": true, "referer": true,
+	"request": true, "request-target": true, "resolve": true, "retry": true,
+	"retry-delay": true, "retry-max-time": true, "sasl-authzid": true, "service-name": true,
+	"socks4": true, "socks4a": true, "socks5": true, "socks5-gssapi-service": true,
+	"socks5-hostname": true, "speed-limit": true, "speed-time": true, "stderr": true,
+	"telnet-option": true, "tftp-blksize": true, "time-cond": true, "tls-max": true,
+	"tls13-ciphers": true, "tlsauthtype": true, "tlspassword": true, "tlsuser": true,
+	"trace": true, "trace-ascii": true, "trace-config": true, "unix-socket": true,
+	"upload-file": true, "url": true, "url-query": true, "user": true, "user-agent": true,
+	"variable": true, "vlan-priority": true, "write-out": true,
+}
+
+// curlIgnoredFlags lists the flags that only affect how curl reports the
+// response, which the item has no use for
+var curlIgnoredFlags = map[string]bool{
+	"dump-header": true, "fail": true, "fail-with-body": true, "globoff": true,
+	"include": true, "location": true, "no-buffer": true, "no-progress-meter": true,
+	"output": true, "progress-bar": true, "remote-name": true, "show-error": true,
+	"silent": true, "stderr": true, "trace": true, "trace-ascii": true, "verbose": true,
+	"write-out": true,
+}
+
+// curlCommand is a parsed curl command line
+type curlCommand struct {
+	item        CreateItemRequest
+	unsupported []string
+}
+
+// curlParser accumulates the flags of a curl command line
+type curlParser struct {
+	method      string
+	head        bool
+	get         bool
+	urls        []string
+	headers     []models.PostmanHeader
+	data        strings.Builder
+	hasData     bool
+	fileData    bool // Data read from a file, which is not imported
+	json        bool
+	form        []models.PostmanParam
+	user        *string
+	digest      bool
+	bearer      string
+	compressed  bool
+	unsupported []string
+}
+
+// parseCurlCommand parses a curl command line, as copied from a browser or
+// written for a shell, into a request item
+func parseCurlCommand(command string) (*curlCommand, error) {
+	words, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) > 0 {
+		if program := path.Base(strings.ReplaceAll(words[0], `\`, "/")); program == "curl" || program == "curl.exe" {
+			words = words[1:]
+		}
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("the command has no arguments")
+	}
+
+	p := &curlParser{}
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		// Anything that is not a flag is a URL
+		if len(word) < 2 || word[0] != '-' {
+			p.urls = append(p.urls, word)
+			continue
+		}
+
+		// Long flags
+		if strings.HasPrefix(word, "--") {
+			name := word[2:]
+			var arg string
+			if curlArgumentFlags[name] {
+				if i+1 == len(words) {
+					return nil, fmt.Errorf("%s requires an argument", word)
+				}
+				i++
+				arg = words[i]
+			}
+			if err := p.flag(word, name, arg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Short flags, which may be combined as in -sSL or take their
+		// argument attached as in -XPOST
+		for j := 1; j < len(word); j++ {
+			flag := "-" + string(word[j])
+			name, known := curlShortFlags[word[j]]
+			if !known {
+				p.unsupported = append(p.unsupported, flag)
+				continue
+			}
+			if !curlArgumentFlags[name] {
+				if err := p.flag(flag, name, ""); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			var arg string
+			switch {
+			case j+1 < len(word):
+				arg = word[j+1:]
+			case i+1 < len(words):
+				i++
+				arg = words[i]
+			default:
+				return nil, fmt.Errorf("%s requires an argument", flag)
+			}
+			if err := p.flag(flag, name, arg); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return p.build()
+}
+
+// flag applies a flag, written as flag on the command line, with its argument
+func (p *curlParser) flag(flag, name, arg string) error {
+	switch name {
+	case "request":
+		p.method = arg
+	case "head":
+		p.head = true
+	case "get":
+		p.get = true
+	case "url":
+		p.urls = append(p.urls, arg)
+	case "compressed":
+		p.compressed = true
+
+	case "header":
+		p.header(flag, arg)
+	case "user-agent":
+		p.setHeader("User-Agent", arg)
+	case "referer":
+		p.setHeader("Referer", strings.TrimSuffix(arg, ";auto"))
+	case "cookie":
+		// Without a '=' the argument names a cookie file
+		if !strings.Contains(arg, "=") {
+			p.unsupported = append(p.unsupported, flag+" "+arg)
+			return nil
+		}
+		for i := range p.headers {
+			if strings.EqualFold(p.headers[i].Key, "Cookie") {
+				p.headers[i].Value += "; " + arg
+				return nil
+			}
+		}
+		p.headers = append(p.headers, models.PostmanHeader{Key: "Cookie", Value: arg})
+
+	case "data", "data-ascii", "data-binary", "json":
+		p.json = p.json || name == "json"
+		if strings.HasPrefix(arg, "@") {
+			p.unsupported = append(p.unsupported, flag+" "+arg)
+			p.fileData = true
+			return nil
+		}
+		p.appendData(arg, name == "json")
+	case "data-raw":
+		p.appendData(arg, false)
+	case "data-urlencode":
+		data, ok := curlURLEncode(arg)
+		if !ok {
+			p.unsupported = append(p.unsupported, flag+" "+arg)
+			p.fileData = true
+			return nil
+		}
+		p.appendData(data, false)
+
+	case "form", "form-string":
+		return p.formField(flag, arg, name == "form-string")
+
+	case "user":
+		p.user = &arg
+	case "basic":
+		p.digest = false
+	case "digest":
+		p.digest = true
+	case "oauth2-bearer":
+		p.bearer = arg
+
+	default:
+		if curlIgnoredFlags[name] {
+			return nil
+		}
+		if arg != "" {
+			flag += " " + arg
+		}
+		p.unsupported = append(p.unsupported, flag)
+	}
+	return nil
+}
+
+// header applies a -H argument. As with curl, "Name:" without a value
+// removes the header and "Name;" sends it empty.
+func (p *curlParser) header(flag, arg string) {
+	if strings.HasPrefix(arg, "@") {
+		p.unsupported = append(p.unsupported, flag+" "+arg)
+		return
+	}
+
+	if name, value, ok := strings.Cut(arg, ":"); ok {
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if value == "" {
+			p.removeHeader(name)
+			return
+		}
+		p.headers = append(p.headers, models.PostmanHeader{Key: name, Value: value})
+		return
+	}
+	if name, ok := strings.CutSuffix(strings.TrimSpace(arg), ";"); ok && name != "" {
+		p.headers = append(p.headers, models.PostmanHeader{Key: name, Value: ""})
+		return
+	}
+	p.unsupported = append(p.unsupported, flag+" "+arg)
+}
+
+// setHeader replaces any headers named name with a single one
+func (p *curlParser) setHeader(name, value string) {
+	p.removeHeader(name)
+	p.headers = append(p.headers, models.PostmanHeader{Key: name, Value: value})
+}
+
+// removeHeader removes the headers named name regardless of case
+func (p *curlParser) removeHeader(name string) {
+	kept := p.headers[:0]
+	for _, header := range p.headers {
+		if !strings.EqualFold(header.Key, name) {
+			kept = append(kept, header)
+		}
+	}
+	p.headers = kept
+}
+
+// hasHeader reports whether a header named name is set, regardless of case
+func (p *curlParser) hasHeader(name string) bool {
+	for _, header := range p.headers {
+		if strings.EqualFold(header.Key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendData adds a piece of request data. As with curl, pieces are joined
+// with '&', except that --json pieces are concatenated.
+func (p *curlParser) appendData(data string, json bool) {
+	if p.hasData && !json {
+		p.data.WriteByte('&')
+	}
+	p.data.WriteString(data)
+	p.hasData = true
+}
+
+// curlURLEncode encodes a --data-urlencode argument, which is "content",
+// "=content" or "name=content". The forms reading a file are not supported.
+func curlURLEncode(arg string) (string, bool) {
+	if i := strings.IndexAny(arg, "=@"); i >= 0 {
+		if arg[i] == '@' {
+			return "", false
+		}
+		if i == 0 {
+			return url.QueryEscape(arg[1:]), true
+		}
+		return arg[:i] + "=" + url.QueryEscape(arg[i+1:]), true
+	}
+	return url.QueryEscape(arg), true
+}
+
+// formField applies a -F or --form-string argument. -F values starting with
+// '@' are files, whose path is kept but whose content has to be uploaded
+// separately; values starting with '<' read a field from a file, which is
+// not supported.
+func (p *curlParser) formField(flag, arg string, literal bool) error {
+	name, value, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%s argument must be name=value", flag)
+	}
+
+	if literal || value == "" || (value[0] != '@' && value[0] != '<') {
+		p.form = append(p.form, models.PostmanParam{Key: name, Value: value, Type: "text"})
+		return nil
+	}
+
+	p.unsupported = append(p.unsupported, flag+" "+arg)
+	if value[0] == '<' {
+		return nil
+	}
+
+	param := models.PostmanParam{Key: name, Type: "file"}
+	parts := strings.Split(value[1:], ";")
+	src := parts[0]
+	for _, part := range parts[1:] {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "type":
+			param.ContentType = val
+		case "filename":
+			src = val
+		}
+	}
+	param.Src = src
+	p.form = append(p.form, param)
+	return nil
+}
+
+// build turns the parsed flags into a request item
+func (p *curlParser) build() (*curlCommand, error) {
+	if len(p.urls) == 0 {
+		return nil, fmt.Errorf("the command has no URL")
+	}
+	if (p.hasData || p.fileData) && len(p.form) > 0 {
+		return nil, fmt.Errorf("the command cannot send both data and a form")
+	}
+
+	// Only the first URL is imported
+	p.unsupported = append(p.unsupported, p.urls[1:]...)
+	rawURL := p.urls[0]
+	if !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "{{") {
+		rawURL = "http://" + rawURL
+	}
+
+	item := CreateItemRequest{
+		ItemType: "request",
+		Method:   p.method,
+		BodyMode: models.BodyModeRaw,
+	}
+
+	switch {
+	case p.get:
+		// -G sends the data in the query string
+		if p.hasData {
+			separator := "?"
+			if strings.Contains(rawURL, "?") {
+				separator = "&"
+			}
+			rawURL += separator + p.data.String()
+		}
+	case p.hasData || p.fileData:
+		item.Body = p.data.String()
+		if p.json {
+			if !p.hasHeader("Content-Type") {
+				p.headers = append(p.headers, models.PostmanHeader{Key: "Content-Type", Value: "application/json"})
+			}
+			if !p.hasHeader("Accept") {
+				p.headers = append(p.headers, models.PostmanHeader{Key: "Accept", Value: "application/json"})
+			}
+		} else if !p.hasHeader("Content-Type") {
+			p.headers = append(p.headers, models.PostmanHeader{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		}
+		if item.Method == "" {
+			item.Method = http.MethodPost
+		}
+	case len(p.form) > 0:
+		// The multipart boundary is chosen when the request is sent
+		p.removeHeader("Content-Type")
+		item.BodyMode = models.BodyModeFormData
+		item.BodyData = &models.BodyData{FormData: p.form}
+		if item.Method == "" {
+			item.Method = http.MethodPost
+		}
+	}
+	if item.Method == "" {
+		item.Method = http.MethodGet
+		if p.head {
+			item.Method = http.MethodHead
+		}
+	}
+	item.URL = rawURL
+
+	// With --compressed the encoding is negotiated, and the response
+	// decoded, when the request is sent
+	if p.compressed {
+		p.removeHeader("Accept-Encoding")
+	}
+	item.Headers = p.headers
+
+	switch {
+	case p.bearer != "":
+		item.Auth = &models.Auth{
+			Type:   models.AuthTypeBearer,
+			Params: map[string]string{"token": p.bearer},
+		}
+	case p.user != nil:
+		username, password, _ := strings.Cut(*p.user, ":")
+		authType := models.AuthTypeBasic
+		if p.digest {
+			authType = models.AuthTypeDigest
+		}
+		item.Auth = &models.Auth{
+			Type:   authType,
+			Params: map[string]string{"username": username, "password": password},
+		}
+	}
+
+	return &curlCommand{item: item, unsupported: p.unsupported}, nil
+}
+
+// splitShellWords splits a command line into words the way a POSIX shell
+// does, honouring single and double quotes, ANSI-C $'...' quotes, backslash
+// escapes and line continuations. Variables are not expanded; pipes,
+// redirections, command lists and command substitutions are rejected.
+func splitShellWords(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case ch == '\\':
+			if i+1 == len(command) {
+				word.WriteByte(ch)
+				inWord = true
+				continue
+			}
+			i++
+			switch command[i] {
+			case '\n':
+				// Line continuation
+			case '\r':
+				if i+1 < len(command) && command[i+1] == '\n' {
+					i++
+				}
+			default:
+				word.WriteByte(command[i])
+				inWord = true
+			}
+
+		case ch == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+
+		case ch == '$' && i+1 < len(command) && command[i+1] == '\'':
+			n, err := readANSIQuoted(command[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			inWord = true
+
+		case ch == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '`' || (command[i] == '$' && i+1 < len(command) && command[i+1] == '(') {
+					return nil, fmt.Errorf("command substitution is not supported")
+				}
+				if command[i] == '\\' && i+1 < len(command) {
+					switch command[i+1] {
+					case '$', '`', '"', '\\':
+						i++
+					case '\n':
+						i++
+						continue
+					}
+				}
+				word.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+
+		case ch == '`' || (ch == '$' && i+1 < len(command) && command[i+1] == '('):
+			return nil, fmt.Errorf("command substitution is not supported")
+
+		case ch == '|' || ch == '&' || ch == ';' || ch == '<' || ch == '>':
+			return nil, fmt.Errorf("only a single curl command is supported; found '%c' outside quotes", ch)
+
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// readANSIQuoted decodes the body of a $'...' quote from s into word and
+// returns the number of bytes read, including the closing quote
+func readANSIQuoted(s string, word *strings.Builder) (int, error) {
+	simple := map[byte]byte{
+		'a': '\a', 'b': '\b', 'e': 0x1b, 'E': 0x1b, 'f': '\f', 'n': '\n', 'r': '\r',
+		't': '\t', 'v': '\v', '\\': '\\', '\'': '\'', '"': '"', '?': '?',
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\'' {
+			return i + 1, nil
+		}
+		if s[i] != '\\' || i+1 == len(s) {
+			word.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if decoded, ok := simple[s[i]]; ok {
+			word.WriteByte(decoded)
+			continue
+		}
+
+		// Numeric escapes: \nnn octal, \xHH, \uHHHH and \UHHHHHHHH
+		kind, start := s[i], i+1
+		base, maxDigits := 16, 0
+		switch {
+		case kind == 'x':
+			maxDigits = 2
+		case kind == 'u':
+			maxDigits = 4
+		case kind == 'U':
+			maxDigits = 8
+		case kind >= '0' && kind <= '7':
+			base, maxDigits, start = 8, 3, i
+		}
+		end := start
+		for end < len(s) && end-start < maxDigits && isDigitIn(s[end], base) {
+			end++
+		}
+		if end == start {
+			word.WriteByte('\\')
+			word.WriteByte(kind)
+			continue
+		}
+		value, _ := strconv.ParseUint(s[start:end], base, 32)
+		if kind == 'u' || kind == 'U' {
+			word.WriteRune(rune(value))
+		} else {
+			word.WriteByte(byte(value))
+		}
+		i = end - 1
+	}
+	return 0, fmt.Errorf("unterminated $' quote")
+}
+
+// isDigitIn reports whether ch is a digit in base 8 or 16
+func isDigitIn(ch byte, base int) bool {
+	switch {
+	case ch >= '0' && ch <= '7':
+		return true
+	case ch == '8' || ch == '9':
+		return base == 16
+	case (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F'):
+		return base == 16
+	}
+	return false
+}
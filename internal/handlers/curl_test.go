@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"postman-runner/internal/models"
+)
+
+func TestParseCurlCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		method      string
+		url         string
+		headers     []models.PostmanHeader
+		body        string
+		unsupported []string
+		err         string
+	}{
+		{
+			name:    "attached short flag argument",
+			command: `curl -XPOST https://example.com/users`,
+			method:  "POST",
+			url:     "https://example.com/users",
+		},
+		{
+			name:    "combined short flags",
+			command: `curl -sSL https://example.com`,
+			method:  "GET",
+			url:     "https://example.com",
+		},
+		{
+			name:    "combined short flags ending with one taking an argument",
+			command: `curl -sX PUT example.com`,
+			method:  "PUT",
+			url:     "http://example.com",
+		},
+		{
+			name:        "data read from a file",
+			command:     `curl --data @body.json https://example.com`,
+			method:      "POST",
+			url:         "https://example.com",
+			headers:     []models.PostmanHeader{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+			unsupported: []string{"--data @body.json"},
+		},
+		{
+			name:    "data pieces joined",
+			command: `curl -d a=1 --data-raw b=2 --data-urlencode 'c=x y' https://example.com`,
+			method:  "POST",
+			url:     "https://example.com",
+			headers: []models.PostmanHeader{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+			body:    "a=1&b=2&c=x+y",
+		},
+		{
+			name:    "header sent empty",
+			command: `curl -H 'X;' https://example.com`,
+			method:  "GET",
+			url:     "https://example.com",
+			headers: []models.PostmanHeader{{Key: "X", Value: ""}},
+		},
+		{
+			name:    "header removed",
+			command: `curl -H 'X: 1' -H 'Y: 2' -H 'X:' https://example.com`,
+			method:  "GET",
+			url:     "https://example.com",
+			headers: []models.PostmanHeader{{Key: "Y", Value: "2"}},
+		},
+		{
+			name:    "short flag missing its argument",
+			command: `curl https://example.com -H`,
+			err:     "-H requires an argument",
+		},
+		{
+			name:    "long flag missing its argument",
+			command: `curl https://example.com --data`,
+			err:     "--data requires an argument",
+		},
+		{
+			name:        "unsupported long flag argument not read as URL",
+			command:     `curl --connect-timeout 5 --socks5 localhost:1080 https://example.com`,
+			method:      "GET",
+			url:         "https://example.com",
+			unsupported: []string{"--connect-timeout 5", "--socks5 localhost:1080"},
+		},
+		{
+			name:        "unsupported short flag argument not read as URL",
+			command:     `curl -Q NOOP -m 10 https://example.com`,
+			method:      "GET",
+			url:         "https://example.com",
+			unsupported: []string{"-Q NOOP", "-m 10"},
+		},
+		{
+			name:    "no URL",
+			command: `curl -s`,
+			err:     "the command has no URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := parseCurlCommand(tt.command)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			item := cmd.item
+			if item.Method != tt.method {
+				t.Errorf("method = %q, want %q", item.Method, tt.method)
+			}
+			if item.URL != tt.url {
+				t.Errorf("url = %q, want %q", item.URL, tt.url)
+			}
+			if len(item.Headers) != 0 || len(tt.headers) != 0 {
+				if !reflect.DeepEqual(item.Headers, tt.headers) {
+					t.Errorf("headers = %v, want %v", item.Headers, tt.headers)
+				}
+			}
+			if item.Body != tt.body {
+				t.Errorf("body = %q, want %q", item.Body, tt.body)
+			}
+			if !reflect.DeepEqual(cmd.unsupported, tt.unsupported) {
+				t.Errorf("unsupported = %q, want %q", cmd.unsupported, tt.unsupported)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDiffChanges bounds the changes listed for a body
+const maxDiffChanges = 1000
+
+// maxLineDiffCells bounds the table of the line diff. Bodies whose differing
+// region is larger are reported as entirely replaced in that region.
+const maxLineDiffCells = 4 << 20
+
+// jsonPathIdentifier matches keys that can be written in dot notation
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// DiffExecutions handles GET /executions/diff?a=..&b=... The responses of two
+// stored executions are compared: status, headers, and bodies, structurally
+// when both are JSON or line by line otherwise. Volatile body values are
+// left out with repeated ignore parameters holding JSONPaths, where * matches
+// any key or index and a leading $.. matches at any depth (e.g.
+// ignore=$..id&ignore=$.meta.timestamp). Headers are left out with
+// ignore_headers, a comma-separated list of names.
+func (h *ExecutionHandler) DiffExecutions(c *gin.Context) {
+	idA, errA := strconv.Atoi(c.Query("a"))
+	idB, errB := strconv.Atoi(c.Query("b"))
+	if errA != nil || errB != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Query parameters a and b must be valid execution IDs",
+		})
+		return
+	}
+
+	var ignored []ignorePath
+	for _, path := range c.QueryArray("ignore") {
+		parsed, err := parseIgnorePath(path)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_ignore_path",
+				Message: fmt.Sprintf("Invalid ignore path '%s': %v", path, err),
+			})
+			return
+		}
+		ignored = append(ignored, parsed)
+	}
+	ignoredHeaders := make(map[string]bool)
+	for _, list := range c.QueryArray("ignore_headers") {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				ignoredHeaders[textproto.CanonicalMIMEHeaderKey(name)] = true
+			}
+		}
+	}
+
+	executions := make([]*models.Execution, 2)
+	for i, id := range []int{idA, idB} {
+		execution, err := fetchExecution(h.db, id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "not_found",
+				Message: fmt.Sprintf("Execution %d not found", id),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to fetch execution",
+			})
+			return
+		}
+		executions[i] = execution
+	}
+	a, b := executions[0], executions[1]
+
+	diff := models.ExecutionDiff{
+		A: idA,
+		B: idB,
+		Status: models.StatusDiff{
+			A:       a.ResponseStatus,
+			B:       b.ResponseStatus,
+			Changed: !equalStatus(a.ResponseStatus, b.ResponseStatus),
+		},
+		Headers: diffHeaders(a.ResponseHeaders, b.ResponseHeaders, ignoredHeaders),
+		Body:    diffBodies(a, b, ignored),
+	}
+	diff.Identical = !diff.Status.Changed && diff.Body.Equal &&
+		len(diff.Headers.Added) == 0 && len(diff.Headers.Removed) == 0 && len(diff.Headers.Changed) == 0
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func equalStatus(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// diffHeaders compares response headers, skipping the ignored names
+func diffHeaders(a, b map[string][]string, ignored map[string]bool) models.HeaderDiff {
+	diff := models.HeaderDiff{
+		Added:   make(map[string][]string),
+		Removed: make(map[string][]string),
+		Changed: make(map[string]models.HeaderChange),
+	}
+	canonicalA, canonicalB := canonicalHeaders(a), canonicalHeaders(b)
+	for name, valuesA := range canonicalA {
+		if ignored[name] {
+			continue
+		}
+		valuesB, ok := canonicalB[name]
+		if !ok {
+			diff.Removed[name] = valuesA
+		} else if !slices.Equal(valuesA, valuesB) {
+			diff.Changed[name] = models.HeaderChange{A: valuesA, B: valuesB}
+		}
+	}
+	for name, valuesB := range canonicalB {
+		if _, ok := canonicalA[name]; !ok && !ignored[name] {
+			diff.Added[name] = valuesB
+		}
+	}
+	return diff
+}
+
+// canonicalHeaders merges headers by canonical name
+func canonicalHeaders(headers map[string][]string) map[string][]string {
+	canonical := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		canonical[key] = append(canonical[key], values...)
+	}
+	return canonical
+}
+
+// diffBodies compares the response bodies of two executions
+func diffBodies(a, b *models.Execution, ignored []ignorePath) models.BodyDiff {
+	diff := models.BodyDiff{Incomplete: a.ResponseTruncated || b.ResponseTruncated}
+
+	if a.ResponseBodyEncoding == models.BodyEncodingBase64 || b.ResponseBodyEncoding == models.BodyEncodingBase64 {
+		diff.Mode = models.DiffModeBinary
+		diff.Equal = a.ResponseBodyEncoding == b.ResponseBodyEncoding && a.ResponseBody == b.ResponseBody
+		return diff
+	}
+
+	jsonA, okA := decodeJSONBody(a.ResponseBody)
+	jsonB, okB := decodeJSONBody(b.ResponseBody)
+	if okA && okB {
+		diff.Mode = models.DiffModeJSON
+		d := jsonDiff{ignored: ignored}
+		d.compare(nil, jsonA, jsonB)
+		diff.Changes, diff.Truncated = d.changes, d.truncated
+		diff.Equal = len(d.changes) == 0
+		return diff
+	}
+
+	diff.Mode = models.DiffModeText
+	diff.Lines, diff.Truncated = diffLines(a.ResponseBody, b.ResponseBody)
+	diff.Equal = len(diff.Lines) == 0
+	return diff
+}
+
+// decodeJSONBody decodes a body holding a single JSON value, keeping numbers
+// as written
+func decodeJSONBody(body string) (interface{}, bool) {
+	if strings.TrimSpace(body) == "" {
+		return nil, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	return value, true
+}
+
+// ignorePath is a JSONPath whose values are left out of a diff, along with
+// everything below them
+type ignorePath struct {
+	anywhere bool     // Matches at any depth, from a leading $..
+	segments []string // "*" matches any key or index
+}
+
+func parseIgnorePath(path string) (ignorePath, error) {
+	path = strings.TrimSpace(path)
+	var parsed ignorePath
+	if rest, ok := strings.CutPrefix(path, "$.."); ok {
+		parsed.anywhere = true
+		path = "$." + rest
+		if strings.HasPrefix(rest, "[") {
+			path = "$" + rest
+		}
+	}
+	segments, err := parseJSONPath(strings.ReplaceAll(path, "[*]", ".*"))
+	if err != nil {
+		return parsed, err
+	}
+	if len(segments) == 0 {
+		return parsed, fmt.Errorf("path selects the whole body")
+	}
+	parsed.segments = segments
+	return parsed, nil
+}
+
+// matches reports whether the value at path is ignored
+func (p ignorePath) matches(path []string) bool {
+	for start := 0; start+len(p.segments) <= len(path); start++ {
+		if p.matchesAt(path[start:]) {
+			return true
+		}
+		if !p.anywhere {
+			break
+		}
+	}
+	return false
+}
+
+func (p ignorePath) matchesAt(path []string) bool {
+	for i, segment := range p.segments {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonDiff collects the changes between two decoded JSON values
+type jsonDiff struct {
+	ignored   []ignorePath
+	changes   []models.JSONChange
+	truncated bool
+}
+
+func (d *jsonDiff) isIgnored(path []string) bool {
+	for _, p := range d.ignored {
+		if p.matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *jsonDiff) compare(path []string, a, b interface{}) {
+	if d.truncated || d.isIgnored(path) {
+		return
+	}
+
+	switch va := a.(type) {
+	case map[string]interface{}:
+		if vb, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(va)+len(vb))
+			for key := range va {
+				keys = append(keys, key)
+			}
+			for key := range vb {
+				if _, ok := va[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				d.compareMember(appendSegment(path, key), va, vb, key)
+			}
+			return
+		}
+	case []interface{}:
+		if vb, ok := b.([]interface{}); ok {
+			for i := 0; i < max(len(va), len(vb)); i++ {
+				child := appendSegment(path, strconv.Itoa(i))
+				switch {
+				case i >= len(va):
+					d.add(child, models.DiffAdded, nil, vb[i])
+				case i >= len(vb):
+					d.add(child, models.DiffRemoved, va[i], nil)
+				default:
+					d.compare(child, va[i], vb[i])
+				}
+			}
+			return
+		}
+	}
+
+	if !equalJSON(a, b) {
+		d.add(path, models.DiffChanged, a, b)
+	}
+}
+
+func (d *jsonDiff) compareMember(path []string, a, b map[string]interface{}, key string) {
+	valueA, inA := a[key]
+	valueB, inB := b[key]
+	switch {
+	case !inA:
+		d.add(path, models.DiffAdded, nil, valueB)
+	case !inB:
+		d.add(path, models.DiffRemoved, valueA, nil)
+	default:
+		d.compare(path, valueA, valueB)
+	}
+}
+
+// add records a change unless its path is ignored. a is not encoded for
+// added values, nor b for removed ones.
+func (d *jsonDiff) add(path []string, changeType string, a, b interface{}) {
+	if d.truncated || d.isIgnored(path) {
+		return
+	}
+	if len(d.changes) == maxDiffChanges {
+		d.truncated = true
+		return
+	}
+	change := models.JSONChange{Path: formatJSONPath(path), Type: changeType}
+	if changeType != models.DiffAdded {
+		change.A, _ = json.Marshal(a)
+	}
+	if changeType != models.DiffRemoved {
+		change.B, _ = json.Marshal(b)
+	}
+	d.changes = append(d.changes, change)
+}
+
+// appendSegment returns a copy of path extended by segment
+func appendSegment(path []string, segment string) []string {
+	return append(path[:len(path):len(path)], segment)
+}
+
+// equalJSON compares decoded JSON values. Numbers are equal when their
+// values are, however they are written; integers are compared exactly.
+func equalJSON(a, b interface{}) bool {
+	if numberA, ok := a.(json.Number); ok {
+		if numberB, ok := b.(json.Number); ok && numberA != numberB {
+			if !strings.ContainsAny(string(numberA)+string(numberB), ".eE") {
+				return false
+			}
+			floatA, errA := numberA.Float64()
+			floatB, errB := numberB.Float64()
+			return errA == nil && errB == nil && floatA == floatB
+		}
+	}
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// formatJSONPath writes path segments in the syntax evaluateJSONPath reads
+func formatJSONPath(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range path {
+		switch {
+		case isArrayIndex(segment):
+			b.WriteString("[" + segment + "]")
+		case jsonPathIdentifier.MatchString(segment):
+			b.WriteString("." + segment)
+		case strings.Contains(segment, "'"):
+			b.WriteString(`["` + segment + `"]`)
+		default:
+			b.WriteString("['" + segment + "']")
+		}
+	}
+	return b.String()
+}
+
+func isArrayIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// diffLines lists the lines removed from a and added in b, following a
+// longest common subsequence of lines. Reports whether the list was cut short.
+func diffLines(a, b string) ([]models.LineChange, bool) {
+	linesA, linesB := splitLines(a), splitLines(b)
+
+	// Lines shared at the start and end need no table
+	prefix := 0
+	for prefix < len(linesA) && prefix < len(linesB) && linesA[prefix] == linesB[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(linesA)-prefix && suffix < len(linesB)-prefix &&
+		linesA[len(linesA)-1-suffix] == linesB[len(linesB)-1-suffix] {
+		suffix++
+	}
+	middleA := linesA[prefix : len(linesA)-suffix]
+	middleB := linesB[prefix : len(linesB)-suffix]
+
+	var changes []models.LineChange
+	add := func(changeType string, indexA, indexB int) bool {
+		if len(changes) == maxDiffChanges {
+			return false
+		}
+		change := models.LineChange{Type: changeType}
+		if changeType == models.DiffRemoved {
+			change.LineA = prefix + indexA + 1
+			change.Text = middleA[indexA]
+		} else {
+			change.LineB = prefix + indexB + 1
+			change.Text = middleB[indexB]
+		}
+		changes = append(changes, change)
+		return true
+	}
+
+	n, m := len(middleA), len(middleB)
+	if (n+1)*(m+1) > maxLineDiffCells {
+		for i := range middleA {
+			if !add(models.DiffRemoved, i, 0) {
+				return changes, true
+			}
+		}
+		for j := range middleB {
+			if !add(models.DiffAdded, 0, j) {
+				return changes, true
+			}
+		}
+		return changes, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of middleA[i:] and middleB[j:]
+	width := m + 1
+	lcs := make([]int32, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if middleA[i] == middleB[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		var ok bool
+		switch {
+		case i < n && j < m && middleA[i] == middleB[j]:
+			i, j = i+1, j+1
+			continue
+		case j == m || (i < n && lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			ok = add(models.DiffRemoved, i, j)
+			i++
+		default:
+			ok = add(models.DiffAdded, i, j)
+			j++
+		}
+		if !ok {
+			return changes, true
+		}
+	}
+	return changes, false
+}
+
+// splitLines splits text into lines, ignoring a final line break and \r
+// before line breaks
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
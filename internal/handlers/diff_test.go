@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"postman-runner/internal/models"
+)
+
+func TestDiffLines(t *testing.T) {
+	removed := func(line int, text string) models.LineChange {
+		return models.LineChange{Type: models.DiffRemoved, LineA: line, Text: text}
+	}
+	added := func(line int, text string) models.LineChange {
+		return models.LineChange{Type: models.DiffAdded, LineB: line, Text: text}
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want []models.LineChange
+	}{
+		{name: "equal", a: "a\nb\n", b: "a\nb"},
+		{name: "line endings ignored", a: "a\r\nb\r\n", b: "a\nb\n"},
+		{name: "added line", a: "a\nc", b: "a\nb\nc", want: []models.LineChange{added(2, "b")}},
+		{name: "removed line", a: "a\nb\nc", b: "a\nc", want: []models.LineChange{removed(2, "b")}},
+		{
+			name: "changed line",
+			a:    "a\nb\nc",
+			b:    "a\nB\nc",
+			want: []models.LineChange{removed(2, "b"), added(2, "B")},
+		},
+		{
+			name: "common subsequence kept",
+			a:    "x\na\nb\nc\ny",
+			b:    "a\nz\nb\nc",
+			want: []models.LineChange{removed(1, "x"), added(2, "z"), removed(5, "y")},
+		},
+		{name: "from empty", a: "", b: "a\nb", want: []models.LineChange{added(1, "a"), added(2, "b")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := diffLines(tt.a, tt.b)
+			if truncated {
+				t.Errorf("unexpectedly truncated")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesFallback(t *testing.T) {
+	// Past maxLineDiffCells every line of a is listed as removed before
+	// every line of b as added, so the line they share is not kept
+	lines := func(prefix string, n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return out
+	}
+	a := append(append(lines("a", 10), "common"), lines("c", 2100)...)
+	b := append(append(lines("b", 2100), "common"), lines("d", 10)...)
+	if (len(a)+1)*(len(b)+1) <= maxLineDiffCells {
+		t.Fatalf("test bodies are too small to exceed maxLineDiffCells")
+	}
+
+	changes, truncated := diffLines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if !truncated || len(changes) != maxDiffChanges {
+		t.Fatalf("got %d changes, truncated %v; want %d, truncated", len(changes), truncated, maxDiffChanges)
+	}
+	for i, change := range changes {
+		if change.Type != models.DiffRemoved || change.LineA != i+1 {
+			t.Fatalf("change %d = %+v, want removal of line %d", i, change, i+1)
+		}
+	}
+
+	// Below it the shared line is kept
+	a, b = a[:30], b[len(b)-30:]
+	b[len(b)-11] = "common"
+	changes, _ = diffLines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	for _, change := range changes {
+		if change.Text == "common" {
+			t.Fatalf("common line listed as %s with the LCS diff", change.Type)
+		}
+	}
+}
+
+func TestIgnorePathMatches(t *testing.T) {
+	tests := []struct {
+		ignore string
+		path   []string
+		want   bool
+	}{
+		{"$.id", []string{"id"}, true},
+		{"$.id", []string{"user", "id"}, false},
+		{"$.user", []string{"user", "id"}, true},
+		{"$.user.id", []string{"user"}, false},
+		{"$..id", []string{"id"}, true},
+		{"$..id", []string{"user", "id"}, true},
+		{"$..id", []string{"items", "3", "id", "value"}, true},
+		{"$..id", []string{"user", "ident"}, false},
+		{"$..user.id", []string{"data", "user", "id"}, true},
+		{"$..user.id", []string{"data", "id"}, false},
+		{"$.*.id", []string{"user", "id"}, true},
+		{"$.*.id", []string{"data", "user", "id"}, false},
+		{"$.items[*].id", []string{"items", "0", "id"}, true},
+		{"$.items[*].id", []string{"items", "0", "name"}, false},
+		{"$.items[1]", []string{"items", "1", "id"}, true},
+		{"$.items[1]", []string{"items", "2", "id"}, false},
+		{"$..[*].id", []string{"data", "items", "7", "id"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ignore+" "+strings.Join(tt.path, "."), func(t *testing.T) {
+			p, err := parseIgnorePath(tt.ignore)
+			if err != nil {
+				t.Fatalf("parseIgnorePath(%q): %v", tt.ignore, err)
+			}
+			if got := p.matches(tt.path); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want interface{}
+		ok   bool
+	}{
+		{name: "object", body: `{"a": 1}`, want: map[string]interface{}{"a": json.Number("1")}, ok: true},
+		{name: "surrounding whitespace", body: " [true]\n", want: []interface{}{true}, ok: true},
+		{name: "number kept as written", body: `1.50`, want: json.Number("1.50"), ok: true},
+		{name: "empty", body: "  "},
+		{name: "invalid", body: `{"a":`},
+		{name: "trailing value", body: `{"a": 1} {"b": 2}`},
+		{name: "trailing text", body: `[1] x`},
+		{name: "concatenated numbers", body: `1 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeJSONBody(tt.body)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeJSONBody(%q) = %#v, %v; want %#v, %v", tt.body, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
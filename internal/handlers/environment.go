@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"postman-runner/internal/config"
@@ -333,50 +334,24 @@ func (h *EnvironmentHandler) BatchUpdateEnvironmentVariables(c *gin.Context) {
 		return
 	}
 
-	// Check if environment exists and get current variables
-	var currentVarsJSON []byte
-	err := h.db.QueryRow("SELECT variables FROM environments WHERE id = $1", id).Scan(&currentVarsJSON)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "not_found",
-			Message: "Environment not found",
-		})
-		return
-	}
+	envID, err := strconv.Atoi(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to fetch environment",
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Environment ID must be a valid integer",
 		})
 		return
 	}
 
-	// Parse current variables
-	var currentVars map[string]string
-	if err := json.Unmarshal(currentVarsJSON, &currentVars); err != nil {
-		currentVars = make(map[string]string)
-	}
-
 	// Merge variables (update existing, add new)
-	for key, value := range req.Variables {
-		currentVars[key] = value
-	}
-
-	// Update in database
-	updatedVarsJSON, err := json.Marshal(currentVars)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "json_error",
-			Message: "Failed to encode variables",
+	currentVars, err := mergeEnvironmentVariables(h.db, envID, req.Variables)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Environment not found",
 		})
 		return
 	}
-
-	_, err = h.db.Exec(`
-		UPDATE environments 
-		SET variables = $1, updated_at = NOW() 
-		WHERE id = $2
-	`, updatedVarsJSON, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateProxyOptionsRequest represents the request body for changing an
+// environment's outbound proxy. Omitted fields are left unchanged and empty
+// strings fall back to the global configuration.
+type UpdateProxyOptionsRequest struct {
+	ProxyURL *string `json:"proxy_url"`
+	NoProxy  *string `json:"no_proxy"`
+}
+
+// GetProxyOptions handles GET /environments/:id/proxy. The proxy password
+// is never returned.
+func (h *EnvironmentHandler) GetProxyOptions(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	proxyURL, noProxy, err := loadEnvironmentProxy(h.db, envID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch proxy options",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environment_id": envID,
+		"proxy_url":      redactProxyURL(proxyURL),
+		"no_proxy":       noProxy,
+	})
+}
+
+// UpdateProxyOptions handles PUT /environments/:id/proxy
+func (h *EnvironmentHandler) UpdateProxyOptions(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateProxyOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_json",
+			Message: "Invalid JSON format",
+		})
+		return
+	}
+
+	proxyURL, noProxy, err := loadEnvironmentProxy(h.db, envID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch proxy options",
+		})
+		return
+	}
+
+	if req.ProxyURL != nil {
+		proxyURL = strings.TrimSpace(*req.ProxyURL)
+		if proxyURL != "" {
+			if _, err := validator.ParseProxyURL(proxyURL); err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "invalid_proxy",
+					Message: err.Error(),
+				})
+				return
+			}
+		}
+	}
+	if req.NoProxy != nil {
+		noProxy = normalizeNoProxy(*req.NoProxy)
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE environments SET proxy_url = $1, no_proxy = $2, updated_at = NOW() WHERE id = $3
+	`, nullString(proxyURL), nullString(noProxy), envID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update proxy options",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Proxy options updated successfully",
+		"proxy_url": redactProxyURL(proxyURL),
+		"no_proxy":  noProxy,
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateTLSOptionsRequest represents the request body for changing an
+// environment's certificate verification. Disabling verification requires a
+// reason, which is kept in the audit log together with who made the change.
+type UpdateTLSOptionsRequest struct {
+	SkipVerify *bool  `json:"skip_verify" binding:"required"`
+	ChangedBy  string `json:"changed_by"`
+	Reason     string `json:"reason"`
+}
+
+// GetTLSOptions handles GET /environments/:id/tls
+func (h *EnvironmentHandler) GetTLSOptions(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	var skipVerify bool
+	if err := h.db.QueryRow("SELECT tls_skip_verify FROM environments WHERE id = $1", envID).Scan(&skipVerify); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch TLS options",
+		})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, environment_id, environment_name, skip_verify, changed_by, reason, created_at
+		FROM environment_tls_audit
+		WHERE environment_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, envID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch TLS audit log",
+		})
+		return
+	}
+	defer rows.Close()
+
+	audit := []models.TLSAuditEntry{}
+	for rows.Next() {
+		var entry models.TLSAuditEntry
+		var changedBy, reason sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.EnvironmentID, &entry.EnvironmentName, &entry.SkipVerify, &changedBy, &reason, &entry.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan TLS audit entry",
+			})
+			return
+		}
+		entry.ChangedBy = changedBy.String
+		entry.Reason = reason.String
+		audit = append(audit, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environment_id": envID,
+		"skip_verify":    skipVerify,
+		"audit":          audit,
+	})
+}
+
+// UpdateTLSOptions handles PUT /environments/:id/tls
+func (h *EnvironmentHandler) UpdateTLSOptions(c *gin.Context) {
+	envID, ok := h.parseEnvironmentID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTLSOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "skip_verify is required",
+		})
+		return
+	}
+	if *req.SkipVerify && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "A reason is required to disable certificate verification",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var envName string
+	err = tx.QueryRow(`UPDATE environments SET tls_skip_verify = $1, updated_at = NOW() WHERE id = $2 RETURNING name`, *req.SkipVerify, envID).Scan(&envName)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Environment not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update TLS options",
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO environment_tls_audit (environment_id, environment_name, skip_verify, changed_by, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`, envID, envName, *req.SkipVerify, nullString(req.ChangedBy), nullString(req.Reason))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record TLS audit entry",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit transaction",
+		})
+		return
+	}
+
+	state := "enabled"
+	if *req.SkipVerify {
+		state = "disabled"
+	}
+	log.Printf("TLS certificate verification %s for environment %d by %q: %s", state, envID, req.ChangedBy, req.Reason)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "TLS options updated successfully",
+		"skip_verify": *req.SkipVerify,
+	})
+}
@@ -2,20 +2,28 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/http/httptrace"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"postman-runner/internal/config"
 	"postman-runner/internal/models"
 	"postman-runner/internal/validator"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 type ExecutionHandler struct {
@@ -32,12 +40,53 @@ func NewExecutionHandler(db *sql.DB, cfg *config.Config) *ExecutionHandler {
 
 // ExecutionRequest represents the optional request body for execution
 type ExecutionRequest struct {
-	URL     *string            `json:"url,omitempty"`
-	Headers *map[string]string `json:"headers,omitempty"`
-	Body    *string            `json:"body,omitempty"`
+	URL           *string             `json:"url,omitempty"`
+	Headers       *map[string]string  `json:"headers,omitempty"`
+	Body          *string             `json:"body,omitempty"`
+	EnvironmentID *int                `json:"environment_id,omitempty"`
+	ProxyURL      *string             `json:"proxy_url,omitempty"`
+	NoProxy       *string             `json:"no_proxy,omitempty"`
+	RetryPolicy   *models.RetryPolicy `json:"retry_policy,omitempty"`
+
+	runID int // Set for the executions of a collection run
+}
+
+// executionError describes why an item could not be executed, along with
+// the HTTP status and error code to report it with
+type executionError struct {
+	Status    int
+	Code      string
+	Message   string
+	Variables []string // Set for unresolved_variables errors
+
+	// ExecutionID is the stored history record, if the request got far enough to be recorded
+	ExecutionID int
+}
+
+func (e *executionError) Error() string {
+	return e.Message
+}
+
+// respond writes the error as a JSON response
+func (e *executionError) respond(c *gin.Context) {
+	if e.Code == "unresolved_variables" {
+		c.JSON(e.Status, models.UnresolvedVariablesResponse{
+			Error:     e.Code,
+			Message:   e.Message,
+			Variables: e.Variables,
+		})
+		return
+	}
+	c.JSON(e.Status, models.ErrorResponse{
+		Error:   e.Code,
+		Message: e.Message,
+	})
 }
 
-// ExecuteRequest handles POST /items/:id/execute
+// ExecuteRequest handles POST /items/:id/execute. While in progress, the
+// execution is listed by GET /items/:id/executions and can be cancelled
+// through DELETE /executions/:id. It is cancelled as well if the caller
+// disconnects.
 func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 	itemIDStr := c.Param("id")
 	itemID, err := strconv.Atoi(itemIDStr)
@@ -50,45 +99,30 @@ func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 	}
 
 	// Fetch item from database
-	var item models.CollectionItem
-	err = h.db.QueryRow(`
-		SELECT id, name, item_type, method, url, headers, body
-		FROM collection_items
-		WHERE id = $1
-	`, itemID).Scan(
-		&item.ID,
-		&item.Name,
-		&item.ItemType,
-		&item.Method,
-		&item.URL,
-		&item.Headers,
-		&item.Body,
-	)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "not_found",
-			Message: "Item not found",
-		})
+	item, execErr := h.fetchExecutableItem(itemID, "request")
+	if execErr != nil {
+		execErr.respond(c)
 		return
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to fetch item",
-		})
+
+	execReq, variables, ok := h.bindExecutionRequest(c)
+	if !ok {
 		return
 	}
 
-	// Validate item type
-	if item.ItemType != "request" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_item_type",
-			Message: "Only items of type 'request' can be executed",
-		})
+	response, execErr := h.executeItem(c.Request.Context(), item, execReq, variables)
+	if execErr != nil {
+		execErr.respond(c)
 		return
 	}
 
+	c.JSON(http.StatusOK, response)
+}
+
+// bindExecutionRequest parses the optional overrides of an execute call and
+// loads the variables of the selected environment, writing an error response
+// if either fails
+func (h *ExecutionHandler) bindExecutionRequest(c *gin.Context) (ExecutionRequest, map[string]string, bool) {
 	// Parse optional request body for overrides (URL with variables replaced, etc.)
 	var execReq ExecutionRequest
 	if c.Request.ContentLength > 0 {
@@ -99,7 +133,7 @@ func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 				Error:   "invalid_request",
 				Message: fmt.Sprintf("Invalid request body: %v", err),
 			})
-			return
+			return execReq, nil, false
 		}
 
 		// Extract only the fields we care about
@@ -120,15 +154,257 @@ func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 				execReq.Headers = &headers
 			}
 		}
+		if envIDVal, ok := rawBody["environment_id"].(float64); ok {
+			envID := int(envIDVal)
+			execReq.EnvironmentID = &envID
+		}
+		if proxyVal, ok := rawBody["proxy_url"].(string); ok && proxyVal != "" {
+			if _, err := validator.ParseProxyURL(proxyVal); err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "invalid_proxy",
+					Message: err.Error(),
+				})
+				return execReq, nil, false
+			}
+			execReq.ProxyURL = &proxyVal
+		}
+		if noProxyVal, ok := rawBody["no_proxy"].(string); ok {
+			noProxy := normalizeNoProxy(noProxyVal)
+			execReq.NoProxy = &noProxy
+		}
+		if policyVal, ok := rawBody["retry_policy"].(map[string]interface{}); ok {
+			policy, err := decodeRetryPolicy(policyVal)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "invalid_retry_policy",
+					Message: err.Error(),
+				})
+				return execReq, nil, false
+			}
+			execReq.RetryPolicy = policy
+		}
+	}
+
+	variables, ok := h.loadExecutionVariables(c, execReq)
+	return execReq, variables, ok
+}
+
+// loadExecutionVariables loads the variables of the environment selected by
+// execReq, if any, for {{variable}} substitution, writing an error response
+// if that fails
+func (h *ExecutionHandler) loadExecutionVariables(c *gin.Context, execReq ExecutionRequest) (map[string]string, bool) {
+	variables := make(map[string]string)
+	if execReq.EnvironmentID != nil {
+		var err error
+		variables, err = loadEnvironmentVariables(h.db, *execReq.EnvironmentID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "not_found",
+				Message: "Environment not found",
+			})
+			return nil, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to fetch environment",
+			})
+			return nil, false
+		}
+	}
+
+	return variables, true
+}
+
+// fetchExecutableItem loads an item of type itemType along with everything
+// needed to execute it
+func (h *ExecutionHandler) fetchExecutableItem(itemID int, itemType string) (*models.CollectionItem, *executionError) {
+	var item models.CollectionItem
+	var bodyDataJSON, extractionRulesJSON, assertionsJSON, authJSON, retryPolicyJSON, messagesJSON []byte
+	err := h.db.QueryRow(`
+		SELECT id, collection_id, parent_id, name, item_type, method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy, messages
+		FROM collection_items
+		WHERE id = $1
+	`, itemID).Scan(
+		&item.ID,
+		&item.CollectionID,
+		&item.ParentID,
+		&item.Name,
+		&item.ItemType,
+		&item.Method,
+		&item.URL,
+		&item.Headers,
+		&item.Body,
+		&item.BodyMode,
+		&bodyDataJSON,
+		&extractionRulesJSON,
+		&assertionsJSON,
+		&authJSON,
+		&retryPolicyJSON,
+		&messagesJSON,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, &executionError{Status: http.StatusNotFound, Code: "not_found", Message: "Item not found"}
+	}
+	if err != nil {
+		return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to fetch item"}
+	}
+
+	// Validate item type
+	if item.ItemType != itemType {
+		return nil, &executionError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_item_type",
+			Message: fmt.Sprintf("Only items of type '%s' can be executed", itemType),
+		}
+	}
+
+	item.BodyData = parseBodyData(bodyDataJSON)
+	item.Auth = parseAuth(authJSON)
+	item.RetryPolicy = parseRetryPolicy(retryPolicyJSON)
+	item.Messages = parseWebSocketMessages(messagesJSON)
+
+	// Parse extraction_rules
+	if err := json.Unmarshal(extractionRulesJSON, &item.ExtractionRules); err != nil {
+		item.ExtractionRules = []models.ExtractionRule{}
+	}
+
+	// Parse assertions
+	if err := json.Unmarshal(assertionsJSON, &item.Assertions); err != nil {
+		item.Assertions = []models.Assertion{}
+	}
+
+	return &item, nil
+}
+
+// executeItem runs a single request item through variable substitution, SSRF
+// validation, the HTTP call and extraction rules, and records the outcome in
+// the execution history. Extracted variables are written back to the
+// environment when execReq selects one. Once ctx ends, the request is
+// abandoned and a cancelled error returned.
+func (h *ExecutionHandler) executeItem(ctx context.Context, item *models.CollectionItem, execReq ExecutionRequest, variables map[string]string) (*models.ExecutionResponse, *executionError) {
+	record := newExecutionRecord(item, execReq)
+	response, execErr := h.performExecution(ctx, item, execReq, variables, &record)
+	h.recordExecution(&record, response, execErr)
+
+	return response, execErr
+}
+
+// newExecutionRecord starts the history record of an execution of item
+func newExecutionRecord(item *models.CollectionItem, execReq ExecutionRequest) models.Execution {
+	itemID := item.ID
+	record := models.Execution{
+		ItemID:        &itemID,
+		EnvironmentID: execReq.EnvironmentID,
+	}
+	if execReq.runID != 0 {
+		record.RunID = &execReq.runID
+	}
+	return record
+}
+
+// recordExecution stores the outcome of an execution in the history and sets
+// the ID of the stored record on response or execErr
+func (h *ExecutionHandler) recordExecution(record *models.Execution, response *models.ExecutionResponse, execErr *executionError) {
+	// Nothing to record if the request could not even be built
+	if record.Method == "" {
+		return
+	}
+
+	if execErr != nil {
+		record.Error = execErr.Message
+	}
+	if response != nil {
+		record.ResponseStatus = &response.Status
+		record.ResponseHeaders = response.Headers
+		record.ResponseBody = response.Body
+		record.ResponseBodyEncoding = response.BodyEncoding
+		record.ResponseSizeBytes = response.SizeBytes
+		record.ResponseTruncated = response.Truncated
+		record.DurationMs = response.DurationMs
+		record.Attempts = response.Attempts
+	}
+
+	executionID, err := h.saveExecution(record)
+	if err != nil {
+		log.Printf("Failed to record execution of item %d: %v", *record.ItemID, err)
+		return
+	}
+	if response != nil {
+		response.ExecutionID = executionID
+	}
+	if execErr != nil {
+		execErr.ExecutionID = executionID
+	}
+}
+
+// performExecution does the work of executeItem. The resolved request is
+// written into record as soon as it is known.
+func (h *ExecutionHandler) performExecution(ctx context.Context, item *models.CollectionItem, execReq ExecutionRequest, variables map[string]string, record *models.Execution) (*models.ExecutionResponse, *executionError) {
+	prepared, execErr := h.prepareExecution(ctx, item, execReq, variables, record)
+	if execErr != nil {
+		if cancelErr := cancelledError(ctx); cancelErr != nil {
+			record.Cancelled = true
+			return nil, cancelErr
+		}
+		return nil, execErr
 	}
+	req, auth, opts := prepared.req, prepared.auth, prepared.opts
 
+	ctx, cancel, release := cancellable(ctx)
+	defer release()
+	defer h.startExecution(record, cancel)()
+
+	// Execute request
+	startTime := time.Now()
+	response, err := h.executeHTTPRequest(ctx, req.Method, req.URL, req.Headers, req.Body, opts)
+	if err == nil && response.Status == http.StatusUnauthorized && auth != nil {
+		response, err = h.retryUnauthorized(ctx, auth, req, response, opts)
+	}
+	duration := time.Since(startTime)
+
+	h.saveCookies(opts, execReq)
+
+	if err != nil {
+		record.DurationMs = duration.Milliseconds()
+		var retryErr *retryError
+		if errors.As(err, &retryErr) {
+			record.Attempts = retryErr.attempts
+		}
+		if cancelErr := cancelledError(ctx); cancelErr != nil {
+			record.Cancelled = true
+			return nil, cancelErr
+		}
+		return nil, &executionError{
+			Status:  http.StatusBadGateway,
+			Code:    "execution_error",
+			Message: fmt.Sprintf("Failed to execute request: %v", err),
+		}
+	}
+
+	response.DurationMs = duration.Milliseconds()
+
+	h.processResponse(item, execReq, response)
+
+	return response, nil
+}
+
+// preparedRequest is a request that passed validation, with credentials
+// applied, along with what it is to be sent with
+type preparedRequest struct {
+	req  *authRequest
+	auth *models.Auth
+	opts requestOptions
+}
+
+// prepareExecution resolves variables, overrides and auth for an item and
+// runs the SSRF checks. The request is written into record before
+// credentials are added to it.
+func (h *ExecutionHandler) prepareExecution(ctx context.Context, item *models.CollectionItem, execReq ExecutionRequest, variables map[string]string, record *models.Execution) (*preparedRequest, *executionError) {
 	// Extract request details with overrides
 	if !item.Method.Valid {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: "Request is missing method",
-		})
-		return
+		return nil, &executionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Request is missing method"}
 	}
 
 	method := item.Method.String
@@ -142,20 +418,11 @@ func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 	}
 
 	if urlStr == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
+		return nil, &executionError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_request",
 			Message: "Request URL is missing. Provide URL in request body or ensure item has a URL.",
-		})
-		return
-	}
-
-	// Perform SSRF validation
-	if err := validator.ValidateExecutionURL(urlStr, h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
-		c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Error:   "ssrf_protection",
-			Message: fmt.Sprintf("URL blocked by SSRF protection: %v", err),
-		})
-		return
+		}
 	}
 
 	// Parse headers with optional overrides
@@ -173,66 +440,377 @@ func (h *ExecutionHandler) ExecuteRequest(c *gin.Context) {
 		}
 	}
 
-	// Get body with optional override
-	body := ""
+	// Get body with optional override; an override is always sent as raw
+	bodyMode := item.BodyMode
+	rawBody := ""
 	if execReq.Body != nil {
-		body = *execReq.Body
+		bodyMode = models.BodyModeRaw
+		rawBody = *execReq.Body
 	} else if item.Body.Valid {
-		body = item.Body.String
+		rawBody = item.Body.String
 	}
 
-	// Execute request
-	startTime := time.Now()
-	response, err := h.executeHTTPRequest(method, urlStr, headers, body)
-	duration := time.Since(startTime)
+	// Resolve {{variable}} placeholders before any URL validation
+	resolver := newVariableResolver(variables)
+	urlStr = resolver.Resolve(urlStr)
+	resolvedHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		resolvedHeaders[resolver.Resolve(key)] = resolver.Resolve(value)
+	}
+	headers = resolvedHeaders
+
+	// GraphQL operations sent with GET travel in the query string instead of a body
+	var body string
+	var err error
+	if bodyMode == models.BodyModeGraphQL && method == http.MethodGet {
+		urlStr, err = graphQLQueryURL(urlStr, item.BodyData, resolver)
+	} else {
+		body, err = buildRequestBody(bodyMode, rawBody, item.BodyData, resolver, headers)
+	}
+	if err != nil {
+		return nil, &executionError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_body",
+			Message: fmt.Sprintf("Failed to build request body: %v", err),
+		}
+	}
 
+	// Auth may be inherited from a parent folder or the collection
+	auth, err := resolveItemAuth(h.db, item)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, models.ErrorResponse{
-			Error:   "execution_error",
-			Message: fmt.Sprintf("Failed to execute request: %v", err),
-		})
+		return nil, &executionError{
+			Status:  http.StatusInternalServerError,
+			Code:    "database_error",
+			Message: "Failed to resolve request authentication",
+		}
+	}
+	auth = resolveAuthVariables(auth, resolver)
+
+	// Credentials are added after the request is recorded so that they are
+	// not kept in the execution history
+	record.Method = method
+	record.URL = urlStr
+	record.RequestHeaders = copyHeaders(headers)
+	record.RequestBody = body
+
+	if unresolved := resolver.Unresolved(); len(unresolved) > 0 {
+		return nil, &executionError{
+			Status:    http.StatusBadRequest,
+			Code:      "unresolved_variables",
+			Message:   fmt.Sprintf("Request references undefined variables: %s", strings.Join(unresolved, ", ")),
+			Variables: unresolved,
+		}
+	}
+
+	// Perform SSRF validation; websocket items are checked on their upgrade target
+	validateURL := validator.ValidateExecutionURL
+	if item.ItemType == "websocket" {
+		validateURL = validator.ValidateWebSocketURL
+	}
+	if err := validateURL(urlStr, h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
+		return nil, &executionError{
+			Status:  http.StatusForbidden,
+			Code:    "ssrf_protection",
+			Message: fmt.Sprintf("URL blocked by SSRF protection: %v", err),
+		}
+	}
+
+	opts, execErr := h.loadRequestOptions(execReq)
+	if execErr != nil {
+		return nil, execErr
+	}
+
+	// A policy given for the execution or the run takes precedence over the item's
+	retryPolicy := item.RetryPolicy
+	if execReq.RetryPolicy != nil {
+		retryPolicy = execReq.RetryPolicy
+	}
+	opts.retry = newRetrier(retryPolicy)
+
+	req := &authRequest{Method: method, URL: urlStr, Headers: headers, Body: body}
+	if execErr := h.applyAuth(ctx, auth, req, opts); execErr != nil {
+		return nil, execErr
+	}
+
+	return &preparedRequest{req: req, auth: auth, opts: opts}, nil
+}
+
+// saveCookies persists the cookie jar of opts, if any. Failures are only logged.
+func (h *ExecutionHandler) saveCookies(opts requestOptions, execReq ExecutionRequest) {
+	if opts.jar == nil {
 		return
 	}
+	if err := opts.jar.save(h.db); err != nil {
+		log.Printf("Failed to save cookies of environment %d: %v", *execReq.EnvironmentID, err)
+	}
+}
 
-	response.DurationMs = duration.Milliseconds()
-	c.JSON(http.StatusOK, response)
+// processResponse evaluates the item's assertions against a response and
+// writes extracted variables back to the environment. Variables that cannot
+// be saved are reported as extraction errors.
+func (h *ExecutionHandler) processResponse(item *models.CollectionItem, execReq ExecutionRequest, response *models.ExecutionResponse) {
+	// Evaluate assertions
+	response.Assertions = evaluateAssertions(item.Assertions, response)
+
+	// Apply extraction rules and write the results back to the environment
+	if execReq.EnvironmentID != nil && response.Status >= 200 && response.Status < 300 {
+		body, bodyErr := responseText(response)
+		extracted, extractionErrors := applyExtractionRules(item.ExtractionRules, body, bodyErr)
+		if len(extracted) > 0 {
+			if _, err := mergeEnvironmentVariables(h.db, *execReq.EnvironmentID, extracted); err != nil {
+				log.Printf("Failed to save extracted variables to environment %d: %v", *execReq.EnvironmentID, err)
+				for name := range extracted {
+					extractionErrors[name] = "failed to save to the environment"
+				}
+			}
+		}
+		response.ExtractedVariables = extracted
+		response.ExtractionErrors = extractionErrors
+	}
 }
 
-func (h *ExecutionHandler) executeHTTPRequest(method, urlStr string, headers map[string]string, bodyStr string) (*models.ExecutionResponse, error) {
-	// Create HTTP client with timeout and redirect limit
-	client := &http.Client{
-		Timeout: h.cfg.RequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= h.cfg.MaxRedirects {
-				return fmt.Errorf("stopped after %d redirects", h.cfg.MaxRedirects)
+// retryUnauthorized handles a 401 response for auth schemes that can recover
+// from it: digest auth answers the server's challenge and resends the request,
+// and a cached OAuth 2.0 token that was rejected is dropped so the next
+// execution obtains a new one.
+func (h *ExecutionHandler) retryUnauthorized(ctx context.Context, auth *models.Auth, req *authRequest, response *models.ExecutionResponse, opts requestOptions) (*models.ExecutionResponse, error) {
+	switch auth.Type {
+	case models.AuthTypeDigest:
+		authorization, ok, err := digestAuthorization(response.Headers["Www-Authenticate"], req, auth.Params)
+		if err != nil {
+			return nil, fmt.Errorf("digest auth: %w", err)
+		}
+		if !ok {
+			return response, nil
+		}
+		setHeader(req.Headers, "Authorization", authorization)
+		return h.executeHTTPRequest(ctx, req.Method, req.URL, req.Headers, req.Body, opts)
+
+	case models.AuthTypeOAuth2:
+		if usesClientCredentials(auth.Params) {
+			oauth2Tokens.evict(oauth2CacheKey(auth.Params))
+		}
+	}
+	return response, nil
+}
+
+// copyHeaders returns a shallow copy of a header map
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
+
+// requestOptions carries the per-environment state an HTTP request is sent with
+type requestOptions struct {
+	jar   *cookieJar     // Cookies of the environment, if any
+	tls   *tlsSettings   // Client certificates, CAs and verification setting
+	proxy *proxySettings // Outbound proxy, if any
+	retry *retrier       // Retry policy, if more than one attempt is allowed
+}
+
+// newTransport returns the transport to send requests with, or nil when
+// http.DefaultTransport will do. The caller closes its idle connections.
+func (o requestOptions) newTransport() *hostTransport {
+	if o.tls.isDefault() && o.proxy == nil {
+		return nil
+	}
+	return newHostTransport(o.tls, o.proxy)
+}
+
+// loadRequestOptions loads the cookie jar, TLS settings and proxy for an
+// execution, using its environment if one is selected
+func (h *ExecutionHandler) loadRequestOptions(execReq ExecutionRequest) (requestOptions, *executionError) {
+	var opts requestOptions
+	var err error
+	environmentID := execReq.EnvironmentID
+
+	// Executions with an environment share its cookie jar
+	if environmentID != nil {
+		opts.jar, err = loadCookieJar(h.db, *environmentID)
+		if err != nil {
+			return opts, &executionError{
+				Status:  http.StatusInternalServerError,
+				Code:    "database_error",
+				Message: "Failed to load environment cookies",
 			}
-			// Validate redirect URL for SSRF
-			if err := validator.ValidateExecutionURL(req.URL.String(), h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
-				return fmt.Errorf("redirect blocked: %w", err)
+		}
+	}
+
+	opts.tls, err = loadTLSSettings(h.db, environmentID, h.cfg.CertificateEncryptionKey)
+	if err != nil {
+		return opts, &executionError{
+			Status:  http.StatusInternalServerError,
+			Code:    "database_error",
+			Message: "Failed to load TLS certificates",
+		}
+	}
+
+	var execErr *executionError
+	opts.proxy, execErr = h.resolveProxy(execReq)
+	if execErr != nil {
+		return opts, execErr
+	}
+
+	return opts, nil
+}
+
+// resolveProxy picks the proxy for an execution. The proxy URL and the
+// no-proxy list each come from the execution, else its environment, else the
+// global configuration. Proxies other than the global one are user supplied,
+// so their host is subject to the SSRF rules as well.
+func (h *ExecutionHandler) resolveProxy(execReq ExecutionRequest) (*proxySettings, *executionError) {
+	settings := &proxySettings{url: h.cfg.ProxyURL, noProxy: h.cfg.NoProxy}
+	trusted := true
+
+	if execReq.EnvironmentID != nil {
+		proxyURL, noProxy, err := loadEnvironmentProxy(h.db, *execReq.EnvironmentID)
+		if err != nil {
+			return nil, &executionError{
+				Status:  http.StatusInternalServerError,
+				Code:    "database_error",
+				Message: "Failed to load environment proxy",
 			}
-			return nil
-		},
+		}
+		if proxyURL != "" {
+			settings.url = proxyURL
+			trusted = false
+		}
+		if noProxy != "" {
+			settings.noProxy = noProxy
+		}
 	}
 
-	// Prepare request body (only for methods that support bodies)
-	var bodyReader io.Reader
-	methodUpper := strings.ToUpper(method)
-	supportsBody := methodUpper == "POST" || methodUpper == "PUT" || methodUpper == "PATCH" || methodUpper == "DELETE"
+	if execReq.ProxyURL != nil {
+		settings.url = *execReq.ProxyURL
+		trusted = false
+	}
+	if execReq.NoProxy != nil {
+		settings.noProxy = *execReq.NoProxy
+	}
+
+	if settings.url == "" {
+		return nil, nil
+	}
+	if !trusted {
+		if err := validator.ValidateProxyURL(settings.url, h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
+			return nil, &executionError{
+				Status:  http.StatusForbidden,
+				Code:    "ssrf_protection",
+				Message: fmt.Sprintf("Proxy blocked by SSRF protection: %v", err),
+			}
+		}
+	}
+
+	return settings, nil
+}
+
+// executeHTTPRequest sends a request, through the proxy of opts if one
+// applies, retrying it as the retry policy of opts allows. Cookies are read
+// from and stored in the jar of opts, if any, including those set along a
+// redirect chain. The request, and any wait before a retry, ends with ctx.
+func (h *ExecutionHandler) executeHTTPRequest(ctx context.Context, method, urlStr string, headers map[string]string, bodyStr string, opts requestOptions) (*models.ExecutionResponse, error) {
+	client, closeIdle := h.newHTTPClient(opts)
+	defer closeIdle()
+	client.Timeout = h.cfg.RequestTimeout
+
+	if opts.retry == nil {
+		return h.sendRequest(ctx, client, method, urlStr, headers, bodyStr, opts)
+	}
+
+	// Retry according to the policy, recording every attempt
+	var attempts []models.ExecutionAttempt
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now()
+		response, err := h.sendRequest(ctx, client, method, urlStr, headers, bodyStr, opts)
+
+		record := models.ExecutionAttempt{Attempt: attempt, DurationMs: time.Since(startTime).Milliseconds()}
+		if err != nil {
+			record.Error = err.Error()
+		} else {
+			status := response.Status
+			record.Status = &status
+		}
+
+		delay, retry := opts.retry.nextDelay(attempt, response, err)
+		if retry {
+			record.DelayMs = delay.Milliseconds()
+		}
+		attempts = append(attempts, record)
+
+		if !retry {
+			if err != nil {
+				return nil, &retryError{attempts: attempts, err: err}
+			}
+			response.Attempts = attempts
+			return response, nil
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, &retryError{attempts: attempts, err: ctx.Err()}
+		}
+	}
+}
+
+// newHTTPClient builds a client that limits and SSRF-checks redirects and
+// sends requests with the jar, certificates and proxy of opts. The returned
+// function closes its idle connections.
+func (h *ExecutionHandler) newHTTPClient(opts requestOptions) (*http.Client, func()) {
+	client := &http.Client{CheckRedirect: h.checkRedirect}
+	if opts.jar != nil {
+		client.Jar = opts.jar
+	}
+	if transport := opts.newTransport(); transport != nil {
+		client.Transport = transport
+		return client, transport.CloseIdleConnections
+	}
+	return client, func() {}
+}
+
+// checkRedirect limits and SSRF-checks the redirects followed by a client
+func (h *ExecutionHandler) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= h.cfg.MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", h.cfg.MaxRedirects)
+	}
+	// Validate redirect URL for SSRF
+	if err := validator.ValidateExecutionURL(req.URL.String(), h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
+		return fmt.Errorf("redirect blocked: %w", err)
+	}
+	return nil
+}
 
-	if bodyStr != "" && supportsBody {
+// newOutboundRequest creates the request sent upstream. The body is only
+// attached for methods that support one.
+func newOutboundRequest(ctx context.Context, method, urlStr string, headers map[string]string, bodyStr string) (*http.Request, error) {
+	var bodyReader io.Reader
+	if bodyStr != "" && validator.MethodAllowsBody(method) {
 		bodyReader = bytes.NewReader([]byte(bodyStr))
 	}
 
-	// Create request
-	req, err := http.NewRequest(method, urlStr, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	return req, nil
+}
+
+// sendRequest performs one attempt of a request with client and reads the response
+func (h *ExecutionHandler) sendRequest(ctx context.Context, client *http.Client, method, urlStr string, headers map[string]string, bodyStr string, opts requestOptions) (*models.ExecutionResponse, error) {
+	req, err := newOutboundRequest(ctx, method, urlStr, headers, bodyStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace connection phases for the timing breakdown
+	timer := newRequestTimer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
 
 	// Execute request
 	resp, err := client.Do(req)
@@ -241,24 +819,138 @@ func (h *ExecutionHandler) executeHTTPRequest(method, urlStr string, headers map
 	}
 	defer resp.Body.Close()
 
-	// Read response body with size limit
-	limitedReader := io.LimitReader(resp.Body, h.cfg.MaxResponseSize)
+	// Read response body with size limit, reading one extra byte to detect truncation
+	limitedReader := io.LimitReader(resp.Body, h.cfg.MaxResponseSize+1)
 	responseBody, err := io.ReadAll(limitedReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	timing := timer.timing(time.Now())
 
-	// Extract response headers
-	responseHeaders := make(map[string]string)
+	sizeBytes := int64(len(responseBody))
+	truncated := sizeBytes > h.cfg.MaxResponseSize
+	if truncated {
+		responseBody = responseBody[:h.cfg.MaxResponseSize]
+		sizeBytes = h.cfg.MaxResponseSize
+		// Report the full size when the upstream announced it
+		if resp.ContentLength > sizeBytes {
+			sizeBytes = resp.ContentLength
+		}
+	}
+
+	// Extract response headers, keeping every value (e.g. multiple Set-Cookie)
+	responseHeaders := make(map[string][]string, len(resp.Header))
 	for key, values := range resp.Header {
-		if len(values) > 0 {
-			responseHeaders[key] = values[0]
+		responseHeaders[key] = values
+	}
+
+	response := &models.ExecutionResponse{
+		Status:    resp.StatusCode,
+		Headers:   responseHeaders,
+		Truncated: truncated,
+		SizeBytes: sizeBytes,
+		Timing:    &timing,
+	}
+	if resp.TLS != nil {
+		response.TLS = describeTLS(resp.TLS, opts.tls != nil && opts.tls.skipVerify)
+	}
+
+	// Binary bodies cannot travel as a JSON string; send them base64-encoded
+	if isTextBody(resp.Header.Get("Content-Type"), responseBody) {
+		response.Body = string(responseBody)
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(responseBody)
+		response.BodyEncoding = models.BodyEncodingBase64
+	}
+
+	return response, nil
+}
+
+// isTextBody reports whether a response body can be returned as text, based
+// on its content type or, when none is given, on the content itself
+func isTextBody(contentType string, body []byte) bool {
+	if contentType == "" {
+		if len(body) == 0 {
+			return true
 		}
+		contentType = http.DetectContentType(body)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return utf8.Valid(body)
+	}
+	if charset, ok := params["charset"]; ok && !isUTF8Charset(charset) {
+		// Non UTF-8 text is passed through losslessly as base64
+		return false
 	}
+	return isTextMediaType(mediaType)
+}
+
+// isUTF8Charset reports whether a charset parameter names UTF-8 or its ASCII
+// subset
+func isUTF8Charset(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
 
-	return &models.ExecutionResponse{
-		Status:  resp.StatusCode,
-		Headers: responseHeaders,
-		Body:    string(responseBody),
-	}, nil
+// isTextMediaType reports whether a media type is text
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json",
+		"application/xml",
+		"application/javascript",
+		"application/ecmascript",
+		"application/x-www-form-urlencoded",
+		"application/graphql",
+		"application/yaml",
+		"application/x-yaml",
+		"application/x-ndjson",
+		"image/svg+xml":
+		return true
+	}
+
+	return false
+}
+
+// responseText returns the body of a response as UTF-8 text for assertions
+// and extraction rules, decoding text that was base64-encoded because of its
+// charset. Fails if the body is binary or its charset is unknown.
+func responseText(response *models.ExecutionResponse) (string, error) {
+	if response.BodyEncoding != models.BodyEncodingBase64 {
+		return response.Body, nil
+	}
+
+	var contentType string
+	if values := response.Headers["Content-Type"]; len(values) > 0 {
+		contentType = values[0]
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] == "" || !isTextMediaType(mediaType) {
+		return "", errors.New("response body is binary")
+	}
+	encoding, err := htmlindex.Get(params["charset"])
+	if err != nil {
+		return "", fmt.Errorf("response body charset '%s' is not supported", params["charset"])
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(response.Body)
+	if err != nil {
+		return "", errors.New("response body is binary")
+	}
+	text, err := encoding.NewDecoder().Bytes(raw)
+	if err != nil {
+		return "", fmt.Errorf("response body is not valid %s", params["charset"])
+	}
+	return string(text), nil
 }
@@ -0,0 +1,604 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// introspectionQuery is the standard introspection query, including
+// deprecated fields so that queries still using them validate
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      locations
+      isRepeatable
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  fields(includeDeprecated: true) {
+    name
+    args { ...InputValue }
+    type { ...TypeRef }
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType { kind name }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// introspectionSchema is the part of an introspection result needed to
+// rebuild the schema
+type introspectionSchema struct {
+	QueryType        *introspectionTypeRef    `json:"queryType"`
+	MutationType     *introspectionTypeRef    `json:"mutationType"`
+	SubscriptionType *introspectionTypeRef    `json:"subscriptionType"`
+	Types            []introspectionType      `json:"types"`
+	Directives       []introspectionDirective `json:"directives"`
+}
+
+type introspectionType struct {
+	Kind          string                    `json:"kind"`
+	Name          string                    `json:"name"`
+	Fields        []introspectionField      `json:"fields"`
+	InputFields   []introspectionInputValue `json:"inputFields"`
+	Interfaces    []introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []introspectionTypeRef    `json:"enumValues"`
+	PossibleTypes []introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name string                    `json:"name"`
+	Args []introspectionInputValue `json:"args"`
+	Type introspectionTypeRef      `json:"type"`
+}
+
+type introspectionInputValue struct {
+	Name         string               `json:"name"`
+	Type         introspectionTypeRef `json:"type"`
+	DefaultValue *string              `json:"defaultValue"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionDirective struct {
+	Name         string                    `json:"name"`
+	Locations    []string                  `json:"locations"`
+	IsRepeatable bool                      `json:"isRepeatable"`
+	Args         []introspectionInputValue `json:"args"`
+}
+
+// String renders a type reference as in SDL, e.g. [String!]!
+func (t introspectionTypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// preludeNames lists the types and directives every schema gets from the
+// parser's prelude, which must not be defined again
+var preludeNames = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	doc, err := parser.ParseSchema(validator.Prelude)
+	if err != nil {
+		return names
+	}
+	for _, def := range doc.Definitions {
+		names[def.Name] = true
+	}
+	for _, directive := range doc.Directives {
+		names["@"+directive.Name] = true
+	}
+	return names
+})
+
+// buildGraphQLSchema rebuilds a schema from the __schema object of an
+// introspection result, by way of its SDL
+func buildGraphQLSchema(data []byte) (*ast.Schema, error) {
+	var schema introspectionSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %w", err)
+	}
+	if schema.QueryType == nil || schema.QueryType.Name == "" {
+		return nil, fmt.Errorf("introspection result has no query type")
+	}
+
+	built, err := validator.LoadSchema(validator.Prelude, &ast.Source{Name: "introspection", Input: introspectionSDL(&schema)})
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return built, nil
+}
+
+// introspectionSDL writes an introspected schema as SDL
+func introspectionSDL(schema *introspectionSchema) string {
+	var sdl strings.Builder
+	prelude := preludeNames()
+
+	sdl.WriteString("schema {\n  query: " + schema.QueryType.Name + "\n")
+	if schema.MutationType != nil && schema.MutationType.Name != "" {
+		sdl.WriteString("  mutation: " + schema.MutationType.Name + "\n")
+	}
+	if schema.SubscriptionType != nil && schema.SubscriptionType.Name != "" {
+		sdl.WriteString("  subscription: " + schema.SubscriptionType.Name + "\n")
+	}
+	sdl.WriteString("}\n")
+
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || prelude[t.Name] {
+			continue
+		}
+
+		switch t.Kind {
+		case "SCALAR":
+			sdl.WriteString("scalar " + t.Name + "\n")
+
+		case "OBJECT", "INTERFACE":
+			keyword := "type "
+			if t.Kind == "INTERFACE" {
+				keyword = "interface "
+			}
+			sdl.WriteString(keyword + t.Name)
+			if len(t.Interfaces) > 0 {
+				names := make([]string, len(t.Interfaces))
+				for i, iface := range t.Interfaces {
+					names[i] = iface.Name
+				}
+				sdl.WriteString(" implements " + strings.Join(names, " & "))
+			}
+			sdl.WriteString(" {\n")
+			for _, field := range t.Fields {
+				sdl.WriteString("  " + field.Name + sdlArguments(field.Args) + ": " + field.Type.String() + "\n")
+			}
+			sdl.WriteString("}\n")
+
+		case "UNION":
+			names := make([]string, len(t.PossibleTypes))
+			for i, possible := range t.PossibleTypes {
+				names[i] = possible.Name
+			}
+			sdl.WriteString("union " + t.Name + " = " + strings.Join(names, " | ") + "\n")
+
+		case "ENUM":
+			sdl.WriteString("enum " + t.Name + " {\n")
+			for _, value := range t.EnumValues {
+				sdl.WriteString("  " + value.Name + "\n")
+			}
+			sdl.WriteString("}\n")
+
+		case "INPUT_OBJECT":
+			sdl.WriteString("input " + t.Name + " {\n")
+			for _, field := range t.InputFields {
+				sdl.WriteString("  " + sdlInputValue(field) + "\n")
+			}
+			sdl.WriteString("}\n")
+		}
+	}
+
+	for _, directive := range schema.Directives {
+		if prelude["@"+directive.Name] {
+			continue
+		}
+		sdl.WriteString("directive @" + directive.Name + sdlArguments(directive.Args))
+		if directive.IsRepeatable {
+			sdl.WriteString(" repeatable")
+		}
+		sdl.WriteString(" on " + strings.Join(directive.Locations, " | ") + "\n")
+	}
+
+	return sdl.String()
+}
+
+func sdlArguments(args []introspectionInputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = sdlInputValue(arg)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// sdlInputValue writes an argument or input field. Introspection reports
+// default values as GraphQL literals, so they are written as they are.
+func sdlInputValue(value introspectionInputValue) string {
+	s := value.Name + ": " + value.Type.String()
+	if value.DefaultValue != nil {
+		s += " = " + *value.DefaultValue
+	}
+	return s
+}
+
+// validateGraphQLQuery checks a query against a schema. When operationName
+// is given the query must define it; otherwise it must define one operation.
+func validateGraphQLQuery(schema *ast.Schema, query, operationName string) []models.GraphQLError {
+	doc, err := parser.ParseQuery(&ast.Source{Name: "query", Input: query})
+	if err != nil {
+		return graphQLErrors(gqlerror.List{toGQLError(err)})
+	}
+
+	errs := graphQLErrors(validator.Validate(schema, doc))
+	if operationName != "" {
+		if doc.Operations.ForName(operationName) == nil {
+			errs = append(errs, models.GraphQLError{Message: fmt.Sprintf("Unknown operation named %q.", operationName)})
+		}
+	} else if len(doc.Operations) > 1 {
+		errs = append(errs, models.GraphQLError{Message: "operationName is required when the query defines several operations."})
+	}
+	return errs
+}
+
+func toGQLError(err error) *gqlerror.Error {
+	if gqlErr, ok := err.(*gqlerror.Error); ok {
+		return gqlErr
+	}
+	return gqlerror.Wrap(err)
+}
+
+func graphQLErrors(list gqlerror.List) []models.GraphQLError {
+	errs := make([]models.GraphQLError, 0, len(list))
+	for _, err := range list {
+		converted := models.GraphQLError{Message: err.Message}
+		for _, location := range err.Locations {
+			converted.Locations = append(converted.Locations, models.GraphQLLocation{Line: location.Line, Column: location.Column})
+		}
+		errs = append(errs, converted)
+	}
+	return errs
+}
+
+// IntrospectGraphQL handles POST /items/:id/graphql/introspect. An
+// introspection query is sent to the item's URL with its headers and auth,
+// resolved as for ExecuteRequest, and the schema is cached for the item's
+// collection under the resolved URL. Introspections are not kept in the execution history.
+func (h *ExecutionHandler) IntrospectGraphQL(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	item, execErr := h.fetchExecutableItem(itemID, "request")
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	execReq, variables, ok := h.bindExecutionRequest(c)
+	if !ok {
+		return
+	}
+	execReq.Body = nil
+
+	// Only the body and method differ from the item
+	introspection := *item
+	introspection.Method = sql.NullString{String: http.MethodPost, Valid: true}
+	introspection.BodyMode = models.BodyModeGraphQL
+	introspection.BodyData = &models.BodyData{GraphQL: &models.PostmanGraphQL{
+		Query:         introspectionQuery,
+		OperationName: "IntrospectionQuery",
+	}}
+
+	var record models.Execution
+	prepared, execErr := h.prepareExecution(c.Request.Context(), &introspection, execReq, variables, &record)
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+	req, auth, opts := prepared.req, prepared.auth, prepared.opts
+
+	response, err := h.executeHTTPRequest(c.Request.Context(), req.Method, req.URL, req.Headers, req.Body, opts)
+	if err == nil && response.Status == http.StatusUnauthorized && auth != nil {
+		response, err = h.retryUnauthorized(c.Request.Context(), auth, req, response, opts)
+	}
+	h.saveCookies(opts, execReq)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "execution_error",
+			Message: fmt.Sprintf("Failed to execute introspection query: %v", err),
+		})
+		return
+	}
+
+	schemaJSON, err := introspectionResult(response)
+	if err == nil {
+		_, err = buildGraphQLSchema(schemaJSON)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "introspection_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	schema := models.GraphQLSchema{CollectionID: item.CollectionID, URL: record.URL, Schema: schemaJSON}
+	err = h.db.QueryRow(`
+		INSERT INTO graphql_schemas (collection_id, url, schema)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (collection_id, url) DO UPDATE SET
+			schema = EXCLUDED.schema,
+			fetched_at = NOW()
+		RETURNING fetched_at
+	`, schema.CollectionID, schema.URL, string(schemaJSON)).Scan(&schema.FetchedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to cache GraphQL schema",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// introspectionResult extracts the __schema object from the response to an
+// introspection query
+func introspectionResult(response *models.ExecutionResponse) (json.RawMessage, error) {
+	if response.Status != http.StatusOK {
+		return nil, fmt.Errorf("introspection query returned HTTP %d", response.Status)
+	}
+	if response.Truncated {
+		return nil, fmt.Errorf("introspection result exceeds the maximum response size")
+	}
+
+	var result struct {
+		Data *struct {
+			Schema json.RawMessage `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if response.BodyEncoding != "" || json.Unmarshal([]byte(response.Body), &result) != nil {
+		return nil, fmt.Errorf("introspection result is not JSON")
+	}
+	if result.Data == nil || len(result.Data.Schema) == 0 || string(result.Data.Schema) == "null" {
+		if len(result.Errors) > 0 {
+			messages := make([]string, len(result.Errors))
+			for i, e := range result.Errors {
+				messages[i] = e.Message
+			}
+			return nil, fmt.Errorf("introspection query failed: %s", strings.Join(messages, "; "))
+		}
+		return nil, fmt.Errorf("introspection result has no schema")
+	}
+	return result.Data.Schema, nil
+}
+
+// loadGraphQLSchema reads the schema cached for a collection and endpoint
+// URL, or the one fetched last if endpoint is empty. Returns sql.ErrNoRows if
+// there is none.
+func loadGraphQLSchema(db *sql.DB, collectionID int, endpoint string) (*models.GraphQLSchema, error) {
+	schema := models.GraphQLSchema{CollectionID: collectionID}
+	var schemaJSON []byte
+	err := db.QueryRow(`
+		SELECT url, schema, fetched_at FROM graphql_schemas
+		WHERE collection_id = $1 AND ($2 = '' OR url = $2)
+		ORDER BY fetched_at DESC
+		LIMIT 1
+	`, collectionID, endpoint).Scan(&schema.URL, &schemaJSON, &schema.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = schemaJSON
+	return &schema, nil
+}
+
+// GetGraphQLSchema handles GET /collections/:id/graphql/schema. The url
+// query parameter selects the endpoint; without it the schema fetched last
+// is returned.
+func (h *CollectionHandler) GetGraphQLSchema(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Collection ID must be a valid integer",
+		})
+		return
+	}
+
+	schema, err := loadGraphQLSchema(h.db, collectionID, c.Query("url"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No GraphQL schema has been introspected for this collection and URL",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch GraphQL schema",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// ValidateGraphQL handles GET /items/:id/graphql/validate. The item's stored
+// GraphQL query is validated against the schema cached for its collection
+// and URL. {{variables}} in the URL and query are resolved from the
+// environment given by the optional environment_id query parameter.
+func (h *ItemHandler) ValidateGraphQL(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	var collectionID int
+	var itemURL sql.NullString
+	var bodyMode string
+	var bodyDataJSON []byte
+	err = h.db.QueryRow(`
+		SELECT collection_id, url, body_mode, body_data FROM collection_items WHERE id = $1
+	`, itemID).Scan(&collectionID, &itemURL, &bodyMode, &bodyDataJSON)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Item not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch item",
+		})
+		return
+	}
+
+	bodyData := parseBodyData(bodyDataJSON)
+	if bodyMode != models.BodyModeGraphQL || bodyData == nil || bodyData.GraphQL == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_body_mode",
+			Message: "Item does not have a GraphQL body",
+		})
+		return
+	}
+
+	variables := make(map[string]string)
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		envID, err := strconv.Atoi(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_id",
+				Message: "environment_id must be a valid integer",
+			})
+			return
+		}
+		variables, err = loadEnvironmentVariables(h.db, envID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "not_found",
+				Message: "Environment not found",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to fetch environment",
+			})
+			return
+		}
+	}
+
+	resolver := newVariableResolver(variables)
+	endpoint := resolver.Resolve(itemURL.String)
+	if endpoint == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Item has no URL",
+		})
+		return
+	}
+
+	cached, err := loadGraphQLSchema(h.db, collectionID, endpoint)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "no_schema",
+			Message: fmt.Sprintf("No GraphQL schema has been introspected from %s", endpoint),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch GraphQL schema",
+		})
+		return
+	}
+
+	schema, err := buildGraphQLSchema(cached.Schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "invalid_schema",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	errs := validateGraphQLQuery(schema, resolver.Resolve(bodyData.GraphQL.Query), resolver.Resolve(bodyData.GraphQL.OperationName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":      len(errs) == 0,
+		"errors":     errs,
+		"fetched_at": cached.FetchedAt,
+	})
+}
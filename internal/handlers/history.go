@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultExecutionPageSize = 20
+	maxExecutionPageSize     = 100
+)
+
+// insertExecution stores the request of an execution about to be sent,
+// setting the ID and creation time of record. The execution shows as in
+// progress until saveExecution completes it.
+func (h *ExecutionHandler) insertExecution(record *models.Execution) error {
+	requestLimit := h.cfg.MaxStoredResponseSize
+	if !isStorableText(record.RequestBody) {
+		// Binary bodies would be mangled as text, so they are kept base64-encoded
+		record.RequestBody = base64.StdEncoding.EncodeToString([]byte(record.RequestBody))
+		record.RequestBodyEncoding = models.BodyEncodingBase64
+		if requestLimit > 0 {
+			requestLimit -= requestLimit % 4
+		}
+	}
+	record.RequestBody, _ = truncateForStorage(record.RequestBody, requestLimit)
+
+	requestHeadersJSON, err := json.Marshal(record.RequestHeaders)
+	if err != nil {
+		return err
+	}
+
+	return h.db.QueryRow(`
+		INSERT INTO executions (item_id, environment_id, run_id, method, url, request_headers, request_body,
+			request_body_encoding)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, record.ItemID, record.EnvironmentID, record.RunID, record.Method, record.URL, string(requestHeadersJSON),
+		nullString(record.RequestBody), nullString(record.RequestBodyEncoding)).Scan(&record.ID, &record.CreatedAt)
+}
+
+// saveExecution completes an execution record with its outcome, storing its
+// request first if insertExecution was not called, and truncating the
+// response body to the configured history size. Returns the record ID.
+func (h *ExecutionHandler) saveExecution(record *models.Execution) (int, error) {
+	if record.ID == 0 {
+		if err := h.insertExecution(record); err != nil {
+			return 0, err
+		}
+	}
+
+	var storedTruncated bool
+	responseLimit := h.cfg.MaxStoredResponseSize
+	if record.ResponseBodyEncoding == models.BodyEncodingBase64 && responseLimit > 0 {
+		// Cut between groups of 4 characters so that the body still decodes
+		responseLimit -= responseLimit % 4
+	}
+	record.ResponseBody, storedTruncated = truncateForStorage(record.ResponseBody, responseLimit)
+	record.ResponseTruncated = record.ResponseTruncated || storedTruncated
+
+	var responseHeadersJSON interface{}
+	if record.ResponseHeaders != nil {
+		encoded, err := json.Marshal(record.ResponseHeaders)
+		if err != nil {
+			return 0, err
+		}
+		responseHeadersJSON = string(encoded)
+	}
+
+	var attemptsJSON interface{}
+	if len(record.Attempts) > 0 {
+		encoded, err := json.Marshal(record.Attempts)
+		if err != nil {
+			return 0, err
+		}
+		attemptsJSON = string(encoded)
+	}
+
+	var websocketLogJSON interface{}
+	if len(record.WebSocketLog) > 0 {
+		encoded, err := json.Marshal(record.WebSocketLog)
+		if err != nil {
+			return 0, err
+		}
+		websocketLogJSON = string(encoded)
+	}
+
+	var responseBody interface{}
+	if record.ResponseStatus != nil {
+		responseBody = record.ResponseBody
+	}
+
+	err := h.db.QueryRow(`
+		UPDATE executions
+		SET response_status = $1, response_headers = $2, response_body = $3, response_body_encoding = $4,
+			response_size_bytes = $5, response_truncated = $6, duration_ms = $7, attempts = $8,
+			websocket_log = $9, error = $10, cancelled = $11, finished_at = NOW()
+		WHERE id = $12
+		RETURNING finished_at
+	`, record.ResponseStatus, responseHeadersJSON, responseBody, nullString(record.ResponseBodyEncoding),
+		record.ResponseSizeBytes, record.ResponseTruncated, record.DurationMs, attemptsJSON,
+		websocketLogJSON, nullString(record.Error), record.Cancelled, record.ID).Scan(&record.FinishedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return record.ID, nil
+}
+
+// isStorableText reports whether a body can be kept in a TEXT column as is
+func isStorableText(s string) bool {
+	return utf8.ValidString(s) && !strings.Contains(s, "\x00")
+}
+
+// truncateForStorage cuts s to at most limit bytes without splitting a UTF-8
+// sequence and strips bytes that Postgres TEXT columns cannot hold
+func truncateForStorage(s string, limit int64) (string, bool) {
+	truncated := false
+	if limit >= 0 && int64(len(s)) > limit {
+		cut := int(limit)
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+		truncated = true
+	}
+	s = strings.ToValidUTF8(s, "�")
+	s = strings.ReplaceAll(s, "\x00", "")
+	return s, truncated
+}
+
+// ListItemExecutions handles GET /items/:id/executions
+func (h *ExecutionHandler) ListItemExecutions(c *gin.Context) {
+	itemIDStr := c.Param("id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultExecutionPageSize)))
+	if err != nil || limit < 1 || limit > maxExecutionPageSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_pagination",
+			Message: "limit must be an integer between 1 and " + strconv.Itoa(maxExecutionPageSize),
+		})
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_pagination",
+			Message: "offset must be a non-negative integer",
+		})
+		return
+	}
+
+	// Check if item exists
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collection_items WHERE id = $1)", itemID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check item existence",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Item not found",
+		})
+		return
+	}
+
+	var total int
+	err = h.db.QueryRow("SELECT COUNT(*) FROM executions WHERE item_id = $1", itemID).Scan(&total)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to count executions",
+		})
+		return
+	}
+
+	// Bodies and websocket logs are left out of the listing; fetch a single execution for them
+	rows, err := h.db.Query(`
+		SELECT id, item_id, environment_id, method, url, request_headers, NULL, request_body_encoding,
+			response_status, response_headers, NULL, response_body_encoding, response_size_bytes,
+			response_truncated, duration_ms, attempts, NULL, error, cancelled, run_id, created_at, finished_at
+		FROM executions
+		WHERE item_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, itemID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch executions",
+		})
+		return
+	}
+	defer rows.Close()
+
+	executions := []models.Execution{}
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan execution",
+			})
+			return
+		}
+		executions = append(executions, *execution)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"executions": executions,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// GetExecution handles GET /executions/:id
+func (h *ExecutionHandler) GetExecution(c *gin.Context) {
+	executionIDStr := c.Param("id")
+	executionID, err := strconv.Atoi(executionIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Execution ID must be a valid integer",
+		})
+		return
+	}
+
+	execution, err := fetchExecution(h.db, executionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Execution not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch execution",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, execution)
+}
+
+// fetchExecution loads a stored execution. Returns sql.ErrNoRows if it does not exist.
+func fetchExecution(db *sql.DB, executionID int) (*models.Execution, error) {
+	row := db.QueryRow(`
+		SELECT id, item_id, environment_id, method, url, request_headers, request_body, request_body_encoding,
+			response_status, response_headers, response_body, response_body_encoding, response_size_bytes,
+			response_truncated, duration_ms, attempts, websocket_log, error, cancelled, run_id, created_at, finished_at
+		FROM executions
+		WHERE id = $1
+	`, executionID)
+	return scanExecution(row)
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExecution(row rowScanner) (*models.Execution, error) {
+	var execution models.Execution
+	var itemID, environmentID, responseStatus, runID sql.NullInt64
+	var finishedAt sql.NullTime
+	var requestHeadersJSON, responseHeadersJSON, attemptsJSON, websocketLogJSON []byte
+	var requestBody, requestBodyEncoding, responseBody, responseBodyEncoding, errMsg sql.NullString
+
+	err := row.Scan(
+		&execution.ID,
+		&itemID,
+		&environmentID,
+		&execution.Method,
+		&execution.URL,
+		&requestHeadersJSON,
+		&requestBody,
+		&requestBodyEncoding,
+		&responseStatus,
+		&responseHeadersJSON,
+		&responseBody,
+		&responseBodyEncoding,
+		&execution.ResponseSizeBytes,
+		&execution.ResponseTruncated,
+		&execution.DurationMs,
+		&attemptsJSON,
+		&websocketLogJSON,
+		&errMsg,
+		&execution.Cancelled,
+		&runID,
+		&execution.CreatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	execution.ItemID = nullIntPtr(itemID)
+	execution.EnvironmentID = nullIntPtr(environmentID)
+	execution.ResponseStatus = nullIntPtr(responseStatus)
+	execution.RunID = nullIntPtr(runID)
+	if finishedAt.Valid {
+		execution.FinishedAt = &finishedAt.Time
+	}
+	execution.RequestBody = requestBody.String
+	execution.RequestBodyEncoding = requestBodyEncoding.String
+	execution.ResponseBody = responseBody.String
+	execution.ResponseBodyEncoding = responseBodyEncoding.String
+	execution.Error = errMsg.String
+
+	if len(attemptsJSON) > 0 {
+		if err := json.Unmarshal(attemptsJSON, &execution.Attempts); err != nil {
+			execution.Attempts = nil
+		}
+	}
+	if len(websocketLogJSON) > 0 {
+		if err := json.Unmarshal(websocketLogJSON, &execution.WebSocketLog); err != nil {
+			execution.WebSocketLog = nil
+		}
+	}
+
+	// Parse headers from JSON
+	if err := json.Unmarshal(requestHeadersJSON, &execution.RequestHeaders); err != nil {
+		execution.RequestHeaders = make(map[string]string)
+	}
+	if len(responseHeadersJSON) > 0 {
+		if err := json.Unmarshal(responseHeadersJSON, &execution.ResponseHeaders); err != nil {
+			// Records written before multi-value headers hold a single value per header
+			var singleValued map[string]string
+			if err := json.Unmarshal(responseHeadersJSON, &singleValued); err == nil {
+				execution.ResponseHeaders = make(map[string][]string, len(singleValued))
+				for key, value := range singleValued {
+					execution.ResponseHeaders[key] = []string{value}
+				}
+			}
+		}
+	}
+
+	return &execution, nil
+}
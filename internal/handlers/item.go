@@ -6,31 +6,45 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"unicode/utf8"
 
 	"postman-runner/internal/config"
 	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UpdateItemRequest struct {
-	Name            *string                  `json:"name,omitempty"`
-	Method          *string                  `json:"method,omitempty"`
-	URL             *string                  `json:"url,omitempty"`
-	Headers         *[]models.PostmanHeader  `json:"headers,omitempty"`
-	Body            *string                  `json:"body,omitempty"`
-	ExtractionRules *[]models.ExtractionRule `json:"extraction_rules,omitempty"`
+	Name            *string                    `json:"name,omitempty"`
+	Method          *string                    `json:"method,omitempty"`
+	URL             *string                    `json:"url,omitempty"`
+	Headers         *[]models.PostmanHeader    `json:"headers,omitempty"`
+	Body            *string                    `json:"body,omitempty"`
+	BodyMode        *string                    `json:"body_mode,omitempty"`
+	BodyData        *models.BodyData           `json:"body_data,omitempty"`
+	ExtractionRules *[]models.ExtractionRule   `json:"extraction_rules,omitempty"`
+	Assertions      *[]models.Assertion        `json:"assertions,omitempty"`
+	Auth            *models.Auth               `json:"auth,omitempty"`
+	RetryPolicy     *models.RetryPolicy        `json:"retry_policy,omitempty"`
+	Messages        *[]models.WebSocketMessage `json:"messages,omitempty"`
 }
 
 type CreateItemRequest struct {
-	Name            string                  `json:"name" binding:"required"`
-	ItemType        string                  `json:"item_type" binding:"required"` // "folder" or "request"
-	ParentID        *int                    `json:"parent_id,omitempty"`
-	Method          string                  `json:"method,omitempty"`
-	URL             string                  `json:"url,omitempty"`
-	Headers         []models.PostmanHeader  `json:"headers,omitempty"`
-	Body            string                  `json:"body,omitempty"`
-	ExtractionRules []models.ExtractionRule `json:"extraction_rules,omitempty"`
+	Name            string                    `json:"name" binding:"required"`
+	ItemType        string                    `json:"item_type" binding:"required"` // "folder", "request" or "websocket"
+	ParentID        *int                      `json:"parent_id,omitempty"`
+	Method          string                    `json:"method,omitempty"`
+	URL             string                    `json:"url,omitempty"`
+	Headers         []models.PostmanHeader    `json:"headers,omitempty"`
+	Body            string                    `json:"body,omitempty"`
+	BodyMode        string                    `json:"body_mode,omitempty"`
+	BodyData        *models.BodyData          `json:"body_data,omitempty"`
+	ExtractionRules []models.ExtractionRule   `json:"extraction_rules,omitempty"`
+	Assertions      []models.Assertion        `json:"assertions,omitempty"`
+	Auth            *models.Auth              `json:"auth,omitempty"`
+	RetryPolicy     *models.RetryPolicy       `json:"retry_policy,omitempty"`
+	Messages        []models.WebSocketMessage `json:"messages,omitempty"`
 }
 
 type ItemHandler struct {
@@ -47,6 +61,31 @@ func NewItemHandler(db *sql.DB, cfg *config.Config) *ItemHandler {
 
 // CreateItem handles POST /collections/:id/items
 func (h *ItemHandler) CreateItem(c *gin.Context) {
+	collectionID, ok := h.requireCollection(c)
+	if !ok {
+		return
+	}
+
+	var createReq CreateItemRequest
+	if err := c.ShouldBindJSON(&createReq); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Name and item_type are required",
+		})
+		return
+	}
+
+	newItem, ok := h.createItem(c, collectionID, createReq)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, newItem)
+}
+
+// requireCollection parses the collection ID of the path and checks that the
+// collection exists, writing an error response if either fails
+func (h *ItemHandler) requireCollection(c *gin.Context) (int, bool) {
 	collectionIDStr := c.Param("id")
 	collectionID, err := strconv.Atoi(collectionIDStr)
 	if err != nil {
@@ -54,10 +93,9 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			Error:   "invalid_id",
 			Message: "Collection ID must be a valid integer",
 		})
-		return
+		return 0, false
 	}
 
-	// Verify collection exists
 	var exists bool
 	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE id = $1)", collectionID).Scan(&exists)
 	if err != nil {
@@ -65,32 +103,48 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			Error:   "database_error",
 			Message: "Failed to check collection existence",
 		})
-		return
+		return 0, false
 	}
 	if !exists {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "not_found",
 			Message: "Collection not found",
 		})
-		return
+		return 0, false
 	}
 
-	var createReq CreateItemRequest
-	if err := c.ShouldBindJSON(&createReq); err != nil {
+	return collectionID, true
+}
+
+// truncateItemName shortens a generated name to fit the VARCHAR(255) name
+// columns of collections and items
+func truncateItemName(name string) string {
+	for utf8.RuneCountInString(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// createItem validates and stores a new item of a collection, writing an
+// error response if either fails
+func (h *ItemHandler) createItem(c *gin.Context, collectionID int, createReq CreateItemRequest) (*models.CollectionItem, bool) {
+	// Validate item_type
+	if createReq.ItemType != "folder" && createReq.ItemType != "request" && createReq.ItemType != "websocket" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: "Name and item_type are required",
+			Error:   "invalid_item_type",
+			Message: "item_type must be 'folder', 'request' or 'websocket'",
 		})
-		return
+		return nil, false
 	}
 
-	// Validate item_type
-	if createReq.ItemType != "folder" && createReq.ItemType != "request" {
+	// Saved messages only make sense on websocket items
+	if createReq.ItemType != "websocket" && createReq.Messages != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "invalid_item_type",
-			Message: "item_type must be 'folder' or 'request'",
+			Message: "Only websocket items can have messages",
 		})
-		return
+		return nil, false
 	}
 
 	// Validate request-specific fields
@@ -100,26 +154,87 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 				Error:   "missing_method",
 				Message: "method is required for request items",
 			})
-			return
+			return nil, false
 		}
 
-		allowedMethods := map[string]bool{
-			"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
-		}
-		if !allowedMethods[createReq.Method] {
+		if err := validator.ValidateMethod(createReq.Method); err != nil {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Error:   "invalid_method",
-				Message: "Method must be one of: GET, POST, PUT, PATCH, DELETE",
+				Message: err.Error(),
 			})
-			return
+			return nil, false
+		}
+		createReq.Method = validator.NormalizeMethod(createReq.Method)
+
+		if createReq.BodyMode == "" {
+			createReq.BodyMode = models.BodyModeRaw
+		}
+		if !isValidBodyMode(createReq.BodyMode) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_body_mode",
+				Message: "body_mode must be one of: raw, urlencoded, formdata, file, graphql",
+			})
+			return nil, false
+		}
+
+		if err := validateAssertions(createReq.Assertions); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_assertions",
+				Message: err.Error(),
+			})
+			return nil, false
+		}
+
+		if err := validateRetryPolicy(createReq.RetryPolicy); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_retry_policy",
+				Message: err.Error(),
+			})
+			return nil, false
 		}
 	}
 
+	// Validate websocket-specific fields; the upgrade is always a GET without a body
+	if createReq.ItemType == "websocket" {
+		if createReq.Method != "" || createReq.Body != "" || createReq.BodyMode != "" || createReq.BodyData != nil ||
+			createReq.ExtractionRules != nil || createReq.Assertions != nil || createReq.RetryPolicy != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_item_type",
+				Message: "websocket items only have a url, headers, messages and auth",
+			})
+			return nil, false
+		}
+
+		if err := validateWebSocketURL(createReq.URL); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_url",
+				Message: err.Error(),
+			})
+			return nil, false
+		}
+
+		if err := validateWebSocketMessages(createReq.Messages); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_messages",
+				Message: err.Error(),
+			})
+			return nil, false
+		}
+	}
+
+	if err := validateAuth(createReq.Auth); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_auth",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+
 	// Verify parent exists if parent_id is provided
 	if createReq.ParentID != nil {
 		var parentExists bool
 		var parentCollectionID int
-		err = h.db.QueryRow(`
+		err := h.db.QueryRow(`
 			SELECT EXISTS(SELECT 1 FROM collection_items WHERE id = $1), collection_id 
 			FROM collection_items WHERE id = $1
 		`, *createReq.ParentID).Scan(&parentExists, &parentCollectionID)
@@ -128,19 +243,20 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 				Error:   "invalid_parent",
 				Message: "Parent item not found",
 			})
-			return
+			return nil, false
 		}
 		if parentCollectionID != collectionID {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Error:   "invalid_parent",
 				Message: "Parent item must belong to the same collection",
 			})
-			return
+			return nil, false
 		}
 	}
 
 	// Calculate sort_order (max + 1 for the parent level)
 	var maxSortOrder sql.NullInt64
+	var err error
 	if createReq.ParentID != nil {
 		err = h.db.QueryRow(`
 			SELECT MAX(sort_order) FROM collection_items 
@@ -157,7 +273,7 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			Error:   "database_error",
 			Message: "Failed to calculate sort order",
 		})
-		return
+		return nil, false
 	}
 
 	sortOrder := 0
@@ -175,23 +291,75 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			Error:   "invalid_extraction_rules",
 			Message: "Failed to serialize extraction_rules",
 		})
-		return
+		return nil, false
 	}
 
-	var extractionRulesBytes []byte
+	// Serialize auth
+	authJSON, err := encodeAuth(createReq.Auth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_auth",
+			Message: "Failed to serialize auth",
+		})
+		return nil, false
+	}
+
+	var bodyDataBytes, extractionRulesBytes, assertionsBytes, authBytes, retryPolicyBytes, messagesBytes []byte
 	if createReq.ItemType == "folder" {
 		err = h.db.QueryRow(`
-			INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, extraction_rules)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			RETURNING id, collection_id, parent_id, name, item_type, sort_order, extraction_rules, created_at, updated_at
-		`, collectionID, createReq.ParentID, createReq.Name, createReq.ItemType, sortOrder, extractionRulesJSON).Scan(
+			INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, extraction_rules, auth)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, collection_id, parent_id, name, item_type, sort_order, extraction_rules, auth, created_at, updated_at
+		`, collectionID, createReq.ParentID, createReq.Name, createReq.ItemType, sortOrder, extractionRulesJSON, authJSON).Scan(
+			&newItem.ID,
+			&newItem.CollectionID,
+			&newItem.ParentID,
+			&newItem.Name,
+			&newItem.ItemType,
+			&newItem.SortOrder,
+			&extractionRulesBytes,
+			&authBytes,
+			&newItem.CreatedAt,
+			&newItem.UpdatedAt,
+		)
+	} else if createReq.ItemType == "websocket" {
+		var headersJSON []byte
+		headersJSON, err = json.Marshal(createReq.Headers)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_headers",
+				Message: "Failed to serialize headers",
+			})
+			return nil, false
+		}
+
+		var messagesJSON interface{}
+		messagesJSON, err = encodeWebSocketMessages(createReq.Messages)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_messages",
+				Message: "Failed to serialize messages",
+			})
+			return nil, false
+		}
+
+		err = h.db.QueryRow(`
+			INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, url, headers, extraction_rules, auth, messages)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id, collection_id, parent_id, name, item_type, sort_order, url, headers, extraction_rules, auth, messages, created_at, updated_at
+		`, collectionID, createReq.ParentID, createReq.Name, createReq.ItemType, sortOrder,
+			createReq.URL, string(headersJSON), extractionRulesJSON, authJSON, messagesJSON).Scan(
 			&newItem.ID,
 			&newItem.CollectionID,
 			&newItem.ParentID,
 			&newItem.Name,
 			&newItem.ItemType,
 			&newItem.SortOrder,
+			&newItem.URL,
+			&newItem.Headers,
 			&extractionRulesBytes,
+			&authBytes,
+			&messagesBytes,
 			&newItem.CreatedAt,
 			&newItem.UpdatedAt,
 		)
@@ -203,15 +371,49 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 				Error:   "invalid_headers",
 				Message: "Failed to serialize headers",
 			})
-			return
+			return nil, false
+		}
+
+		// Serialize assertions
+		if createReq.Assertions == nil {
+			createReq.Assertions = []models.Assertion{}
+		}
+		assertionsJSON, err := json.Marshal(createReq.Assertions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_assertions",
+				Message: "Failed to serialize assertions",
+			})
+			return nil, false
+		}
+
+		// Serialize body_data
+		bodyDataJSON, err := encodeBodyData(createReq.BodyData)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_body_data",
+				Message: "Failed to serialize body_data",
+			})
+			return nil, false
+		}
+
+		// Serialize retry_policy
+		retryPolicyJSON, err := encodeRetryPolicy(createReq.RetryPolicy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_retry_policy",
+				Message: "Failed to serialize retry_policy",
+			})
+			return nil, false
 		}
 
 		err = h.db.QueryRow(`
-			INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, method, url, headers, body, extraction_rules)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-			RETURNING id, collection_id, parent_id, name, item_type, sort_order, method, url, headers, body, extraction_rules, created_at, updated_at
+			INSERT INTO collection_items (collection_id, parent_id, name, item_type, sort_order, method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING id, collection_id, parent_id, name, item_type, sort_order, method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy, created_at, updated_at
 		`, collectionID, createReq.ParentID, createReq.Name, createReq.ItemType, sortOrder,
-			createReq.Method, createReq.URL, string(headersJSON), createReq.Body, extractionRulesJSON).Scan(
+			createReq.Method, createReq.URL, string(headersJSON), createReq.Body, createReq.BodyMode, bodyDataJSON,
+			extractionRulesJSON, string(assertionsJSON), authJSON, retryPolicyJSON).Scan(
 			&newItem.ID,
 			&newItem.CollectionID,
 			&newItem.ParentID,
@@ -222,7 +424,12 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			&newItem.URL,
 			&newItem.Headers,
 			&newItem.Body,
+			&newItem.BodyMode,
+			&bodyDataBytes,
 			&extractionRulesBytes,
+			&assertionsBytes,
+			&authBytes,
+			&retryPolicyBytes,
 			&newItem.CreatedAt,
 			&newItem.UpdatedAt,
 		)
@@ -233,7 +440,7 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 			Error:   "database_error",
 			Message: "Failed to create item",
 		})
-		return
+		return nil, false
 	}
 
 	// Parse extraction_rules back
@@ -241,7 +448,19 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 		newItem.ExtractionRules = []models.ExtractionRule{}
 	}
 
-	c.JSON(http.StatusCreated, newItem)
+	newItem.BodyData = parseBodyData(bodyDataBytes)
+	newItem.Auth = parseAuth(authBytes)
+	newItem.RetryPolicy = parseRetryPolicy(retryPolicyBytes)
+	newItem.Messages = parseWebSocketMessages(messagesBytes)
+
+	// Parse assertions back
+	if assertionsBytes != nil {
+		if err := json.Unmarshal(assertionsBytes, &newItem.Assertions); err != nil {
+			newItem.Assertions = []models.Assertion{}
+		}
+	}
+
+	return &newItem, true
 }
 
 // UpdateItem handles PUT /items/:id (full update)
@@ -275,7 +494,8 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		return
 	}
 
-	// Check if item exists and is a request (not a folder)
+	// Check if item exists; folders only carry a name and auth, websocket
+	// items a url, headers and messages as well
 	var itemType string
 	err = h.db.QueryRow("SELECT item_type FROM collection_items WHERE id = $1", itemID).Scan(&itemType)
 	if err == sql.ErrNoRows {
@@ -293,26 +513,56 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		return
 	}
 
-	if itemType != "request" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_item_type",
-			Message: "Only items of type 'request' can be updated with this endpoint",
-		})
-		return
+	switch itemType {
+	case "folder":
+		if updateReq.Method != nil || updateReq.URL != nil || updateReq.Headers != nil || updateReq.Body != nil ||
+			updateReq.BodyMode != nil || updateReq.BodyData != nil || updateReq.ExtractionRules != nil || updateReq.Assertions != nil ||
+			updateReq.RetryPolicy != nil || updateReq.Messages != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_item_type",
+				Message: "Only name and auth can be updated on folders",
+			})
+			return
+		}
+	case "websocket":
+		if updateReq.Method != nil || updateReq.Body != nil || updateReq.BodyMode != nil || updateReq.BodyData != nil ||
+			updateReq.ExtractionRules != nil || updateReq.Assertions != nil || updateReq.RetryPolicy != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_item_type",
+				Message: "Only name, url, headers, messages and auth can be updated on websocket items",
+			})
+			return
+		}
+		if updateReq.URL != nil {
+			if err := validateWebSocketURL(*updateReq.URL); err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "invalid_url",
+					Message: err.Error(),
+				})
+				return
+			}
+		}
+	default:
+		if updateReq.Messages != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_item_type",
+				Message: "Only websocket items can have messages",
+			})
+			return
+		}
 	}
 
 	// Validate method if provided
 	if updateReq.Method != nil {
-		allowedMethods := map[string]bool{
-			"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
-		}
-		if !allowedMethods[*updateReq.Method] {
+		if err := validator.ValidateMethod(*updateReq.Method); err != nil {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Error:   "invalid_method",
-				Message: "Method must be one of: GET, POST, PUT, PATCH, DELETE",
+				Message: err.Error(),
 			})
 			return
 		}
+		normalized := validator.NormalizeMethod(*updateReq.Method)
+		updateReq.Method = &normalized
 	}
 
 	// Build update query dynamically based on provided fields
@@ -358,6 +608,33 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		argCount++
 	}
 
+	if updateReq.BodyMode != nil {
+		if !isValidBodyMode(*updateReq.BodyMode) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_body_mode",
+				Message: "body_mode must be one of: raw, urlencoded, formdata, file, graphql",
+			})
+			return
+		}
+		updates = append(updates, "body_mode = $"+strconv.Itoa(argCount))
+		args = append(args, *updateReq.BodyMode)
+		argCount++
+	}
+
+	if updateReq.BodyData != nil {
+		bodyDataJSON, err := encodeBodyData(updateReq.BodyData)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_body_data",
+				Message: "Failed to serialize body_data",
+			})
+			return
+		}
+		updates = append(updates, "body_data = $"+strconv.Itoa(argCount))
+		args = append(args, bodyDataJSON)
+		argCount++
+	}
+
 	if updateReq.ExtractionRules != nil {
 		extractionRulesJSON, err := json.Marshal(updateReq.ExtractionRules)
 		if err != nil {
@@ -372,6 +649,90 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		argCount++
 	}
 
+	if updateReq.Assertions != nil {
+		if err := validateAssertions(*updateReq.Assertions); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_assertions",
+				Message: err.Error(),
+			})
+			return
+		}
+		assertionsJSON, err := json.Marshal(updateReq.Assertions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_assertions",
+				Message: "Failed to serialize assertions",
+			})
+			return
+		}
+		updates = append(updates, "assertions = $"+strconv.Itoa(argCount))
+		args = append(args, string(assertionsJSON))
+		argCount++
+	}
+
+	if updateReq.Auth != nil {
+		if err := validateAuth(updateReq.Auth); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_auth",
+				Message: err.Error(),
+			})
+			return
+		}
+		authJSON, err := encodeAuth(updateReq.Auth)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_auth",
+				Message: "Failed to serialize auth",
+			})
+			return
+		}
+		updates = append(updates, "auth = $"+strconv.Itoa(argCount))
+		args = append(args, authJSON)
+		argCount++
+	}
+
+	if updateReq.RetryPolicy != nil {
+		if err := validateRetryPolicy(updateReq.RetryPolicy); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_retry_policy",
+				Message: err.Error(),
+			})
+			return
+		}
+		retryPolicyJSON, err := encodeRetryPolicy(updateReq.RetryPolicy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_retry_policy",
+				Message: "Failed to serialize retry_policy",
+			})
+			return
+		}
+		updates = append(updates, "retry_policy = $"+strconv.Itoa(argCount))
+		args = append(args, retryPolicyJSON)
+		argCount++
+	}
+
+	if updateReq.Messages != nil {
+		if err := validateWebSocketMessages(*updateReq.Messages); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_messages",
+				Message: err.Error(),
+			})
+			return
+		}
+		messagesJSON, err := encodeWebSocketMessages(*updateReq.Messages)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_messages",
+				Message: "Failed to serialize messages",
+			})
+			return
+		}
+		updates = append(updates, "messages = $"+strconv.Itoa(argCount))
+		args = append(args, messagesJSON)
+		argCount++
+	}
+
 	if len(updates) == 0 {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "no_updates",
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"postman-runner/internal/models"
+)
+
+// maxIterationRows bounds the rows of iteration data a run accepts
+const maxIterationRows = 10000
+
+// parseIterationData reads iteration data from a CSV file, whose first row
+// names the variables, or a JSON array of objects. The format is taken from
+// the file name when it has a .csv or .json extension, else from the content.
+func parseIterationData(data []byte, filename string) ([]map[string]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	isJSON := bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		isJSON = true
+	case ".csv":
+		isJSON = false
+	}
+
+	var rows []map[string]string
+	var err error
+	if isJSON {
+		rows, err = parseJSONIterationData(data)
+	} else {
+		rows, err = parseCSVIterationData(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("iteration data has no rows")
+	}
+	if len(rows) > maxIterationRows {
+		return nil, fmt.Errorf("iteration data may have at most %d rows", maxIterationRows)
+	}
+	return rows, nil
+}
+
+// parseJSONIterationData reads an array of objects. Strings are used as
+// they are; other values are substituted as their JSON text, and null as an
+// empty string.
+func parseJSONIterationData(data []byte) ([]map[string]string, error) {
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("iteration data must be a JSON array of objects: %v", err)
+	}
+
+	rows := make([]map[string]string, 0, len(objects))
+	for i, object := range objects {
+		if object == nil {
+			return nil, fmt.Errorf("iteration data row %d is not an object", i+1)
+		}
+		row := make(map[string]string, len(object))
+		for key, raw := range object {
+			var str string
+			switch {
+			case json.Unmarshal(raw, &str) == nil:
+				row[key] = str
+			case string(raw) == "null":
+				row[key] = ""
+			default:
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, raw); err != nil {
+					return nil, fmt.Errorf("iteration data row %d: %v", i+1, err)
+				}
+				row[key] = compact.String()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseCSVIterationData reads a CSV file with a header row. Every row must
+// have as many fields as the header.
+func parseCSVIterationData(data []byte) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("iteration data is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV iteration data: %v", err)
+	}
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("CSV column %d has no name", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("CSV column %q is repeated", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("invalid CSV iteration data on line %d: %v", parseErr.Line, parseErr.Err)
+			}
+			return nil, fmt.Errorf("invalid CSV iteration data: %v", err)
+		}
+		if len(rows) == maxIterationRows {
+			return nil, fmt.Errorf("iteration data may have at most %d rows", maxIterationRows)
+		}
+
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// layerVariables returns the variables of base with those of row layered
+// over them
+func layerVariables(base, row map[string]string) map[string]string {
+	if len(row) == 0 {
+		return base
+	}
+	layered := make(map[string]string, len(base)+len(row))
+	for key, value := range base {
+		layered[key] = value
+	}
+	for key, value := range row {
+		layered[key] = value
+	}
+	return layered
+}
+
+// groupRunIterations groups the results of a data-driven run by iteration.
+// Iterations that did not run, because the run stopped on a failure, are left out.
+func groupRunIterations(data []map[string]string, results []models.RunResult) []models.RunIteration {
+	iterations := make([]models.RunIteration, 0, len(data))
+	for _, result := range results {
+		if result.Iteration < 1 || result.Iteration > len(data) {
+			continue
+		}
+		if len(iterations) == 0 || iterations[len(iterations)-1].Iteration != result.Iteration {
+			iterations = append(iterations, models.RunIteration{
+				Iteration: result.Iteration,
+				Data:      data[result.Iteration-1],
+				Passed:    true,
+				Results:   []models.RunResult{},
+			})
+		}
+		iteration := &iterations[len(iterations)-1]
+		iteration.Results = append(iteration.Results, result)
+		if result.Passed {
+			iteration.PassedRequests++
+		} else {
+			iteration.FailedRequests++
+			iteration.Passed = false
+		}
+	}
+	return iterations
+}
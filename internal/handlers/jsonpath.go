@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/models"
+)
+
+// evaluateJSONPath resolves a simple JSONPath expression against decoded JSON.
+// Supported syntax: $, .field, ['field'], ["field"] and [index] (negative
+// indexes count from the end). The leading $ is optional.
+func evaluateJSONPath(data interface{}, path string) (interface{}, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := data
+	for _, segment := range segments {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("key '%s' not found", segment)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not a valid array index", segment)
+			}
+			if index < 0 {
+				index += len(v)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("index %s out of range", segment)
+			}
+			current = v[index]
+		default:
+			return nil, fmt.Errorf("cannot access '%s' on a non-container value", segment)
+		}
+	}
+
+	return current, nil
+}
+
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+
+	var segments []string
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("empty field name at position %d", start)
+			}
+			segments = append(segments, path[start:i])
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket at position %d", i)
+			}
+			inner := strings.TrimSpace(path[i+1 : i+end])
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				inner = inner[1 : len(inner)-1]
+			} else if _, err := strconv.Atoi(inner); err != nil {
+				return nil, fmt.Errorf("invalid bracket expression '%s'", inner)
+			}
+			segments = append(segments, inner)
+			i += end + 1
+		default:
+			// Allow paths without a leading $ or dot, e.g. "data.token"
+			if i != 0 {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", path[i], i)
+			}
+			path = "." + path
+		}
+	}
+
+	return segments, nil
+}
+
+// jsonValueToString converts a decoded JSON value into its variable form:
+// strings are used as-is, everything else is re-encoded as JSON
+func jsonValueToString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// applyExtractionRules evaluates every enabled rule against a JSON response
+// body. It returns the extracted values and, per variable, why a rule failed.
+func applyExtractionRules(rules []models.ExtractionRule, body string, bodyErr error) (map[string]string, map[string]string) {
+	extracted := make(map[string]string)
+	failures := make(map[string]string)
+
+	var enabled []models.ExtractionRule
+	for _, rule := range rules {
+		if rule.Enabled && rule.JSONPath != "" && rule.VariableName != "" {
+			enabled = append(enabled, rule)
+		}
+	}
+	if len(enabled) == 0 {
+		return extracted, failures
+	}
+
+	var data interface{}
+	if bodyErr == nil {
+		if err := json.Unmarshal([]byte(body), &data); err != nil {
+			bodyErr = errors.New("response body is not valid JSON")
+		}
+	}
+	if bodyErr != nil {
+		for _, rule := range enabled {
+			failures[rule.VariableName] = bodyErr.Error()
+		}
+		return extracted, failures
+	}
+
+	for _, rule := range enabled {
+		value, err := evaluateJSONPath(data, rule.JSONPath)
+		if err != nil {
+			failures[rule.VariableName] = err.Error()
+			continue
+		}
+		extracted[rule.VariableName] = jsonValueToString(value)
+	}
+
+	return extracted, failures
+}
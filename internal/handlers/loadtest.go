@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"postman-runner/internal/middleware"
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// loadTestProgressInterval is how often progress is sent while a load test runs
+const loadTestProgressInterval = time.Second
+
+// loadTestMaxErrorMessages bounds the distinct error messages counted per request
+const loadTestMaxErrorMessages = 20
+
+// LoadTestRequest represents the request body for a load test. The test is
+// bounded by either a number of iterations, shared by all virtual users, or
+// a duration. Users start evenly spread over the ramp-up period.
+type LoadTestRequest struct {
+	EnvironmentID *int  `json:"environment_id,omitempty"`
+	Concurrency   int   `json:"concurrency"`
+	Iterations    int   `json:"iterations,omitempty"`
+	DurationMs    int64 `json:"duration_ms,omitempty"`
+	RampUpMs      int64 `json:"ramp_up_ms,omitempty"`
+}
+
+// validateLoadTest checks a load test against the configured limits
+func (h *ExecutionHandler) validateLoadTest(req LoadTestRequest) error {
+	maxDurationMs := h.cfg.LoadTestMaxDuration.Milliseconds()
+	if req.Concurrency < 1 || req.Concurrency > h.cfg.LoadTestMaxConcurrency {
+		return fmt.Errorf("concurrency must be between 1 and %d", h.cfg.LoadTestMaxConcurrency)
+	}
+	if (req.Iterations > 0) == (req.DurationMs > 0) {
+		return fmt.Errorf("exactly one of iterations and duration_ms is required")
+	}
+	if req.Iterations < 0 || req.Iterations > h.cfg.LoadTestMaxIterations {
+		return fmt.Errorf("iterations must be between 1 and %d", h.cfg.LoadTestMaxIterations)
+	}
+	if req.DurationMs < 0 || req.DurationMs > maxDurationMs {
+		return fmt.Errorf("duration_ms must be between 1 and %d", maxDurationMs)
+	}
+	if req.RampUpMs < 0 || req.RampUpMs > maxDurationMs {
+		return fmt.Errorf("ramp_up_ms must be between 0 and %d", maxDurationMs)
+	}
+	if req.DurationMs > 0 && req.RampUpMs >= req.DurationMs {
+		return fmt.Errorf("ramp_up_ms must be shorter than duration_ms")
+	}
+	return nil
+}
+
+// LoadTest handles POST /items/:id/load-test. Virtual users repeatedly send
+// the request item, or each request of the folder item in tree order, with a
+// shared client whose transport keeps a connection per user alive. Requests
+// are prepared and SSRF-checked once, as for ExecuteRequest, and every
+// request sent counts against the caller's rate limit. Progress is streamed
+// over Server-Sent Events: a "start" event, a "progress" event every second
+// and a final "summary" event with the report.
+//
+// Load test requests are not recorded in the execution history, do not write
+// extracted variables or cookies back to the environment, and are sent once:
+// retry policies and digest challenges do not apply.
+func (h *ExecutionHandler) LoadTest(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	var ltReq LoadTestRequest
+	if err := c.ShouldBindJSON(&ltReq); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("Invalid request body: %v", err),
+		})
+		return
+	}
+	if err := h.validateLoadTest(ltReq); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	items, execErr := h.fetchLoadTestItems(itemID)
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	execReq := ExecutionRequest{EnvironmentID: ltReq.EnvironmentID}
+	variables, ok := h.loadExecutionVariables(c, execReq)
+	if !ok {
+		return
+	}
+
+	test := &loadTest{
+		handler:    h,
+		iterations: int64(ltReq.Iterations),
+		total:      newLoadTestStats(),
+	}
+	if limiter, ok := c.Get(middleware.RateLimiterKey); ok {
+		test.limiter, _ = limiter.(*rate.Limiter)
+	}
+
+	start := models.LoadTestStart{
+		ItemID:      itemID,
+		Concurrency: ltReq.Concurrency,
+		Iterations:  ltReq.Iterations,
+		DurationMs:  ltReq.DurationMs,
+		RampUpMs:    ltReq.RampUpMs,
+	}
+	for i := range items {
+		item := &items[i]
+		record := newExecutionRecord(item, execReq)
+		prepared, execErr := h.prepareExecution(c.Request.Context(), item, execReq, variables, &record)
+		if execErr != nil {
+			if item.ID != itemID {
+				execErr.Message = fmt.Sprintf("Request '%s': %s", item.Name, execErr.Message)
+			}
+			execErr.respond(c)
+			return
+		}
+
+		info := models.LoadTestItemInfo{ItemID: item.ID, Name: item.Name, Method: record.Method, URL: record.URL}
+		test.targets = append(test.targets, loadTestTarget{item: item, info: info, prepared: prepared, stats: newLoadTestStats()})
+		start.Requests = append(start.Requests, info)
+	}
+
+	// Every request of the test shares the environment, hence the same options
+	var closeIdle func()
+	test.client, closeIdle = h.newLoadTestClient(test.targets[0].prepared.opts, ltReq.Concurrency)
+	defer closeIdle()
+
+	// A test bounded by iterations still ends after the maximum duration
+	limit := h.cfg.LoadTestMaxDuration
+	if ltReq.DurationMs > 0 {
+		limit = time.Duration(ltReq.DurationMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	send := func(event string, data interface{}) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	send("start", start)
+
+	test.started = time.Now()
+	done := make(chan struct{})
+	go func() {
+		test.run(ctx, ltReq.Concurrency, time.Duration(ltReq.RampUpMs)*time.Millisecond)
+		close(done)
+	}()
+
+	ticker := time.NewTicker(loadTestProgressInterval)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ticker.C:
+			if c.Request.Context().Err() == nil {
+				send("progress", test.progress())
+			}
+		}
+	}
+
+	// Nobody is left to report to when the caller went away
+	if c.Request.Context().Err() != nil {
+		return
+	}
+
+	report := test.report()
+	report.Reason = models.LoadTestCompleted
+	if ltReq.Iterations > 0 && report.CompletedIterations < int64(ltReq.Iterations) {
+		report.Reason = models.LoadTestTimeout
+	}
+	send("summary", report)
+}
+
+// fetchLoadTestItems loads the request item, or the requests of the folder
+// item, that a load test sends
+func (h *ExecutionHandler) fetchLoadTestItems(itemID int) ([]models.CollectionItem, *executionError) {
+	var collectionID int
+	var itemType string
+	err := h.db.QueryRow("SELECT collection_id, item_type FROM collection_items WHERE id = $1", itemID).Scan(&collectionID, &itemType)
+	if err == sql.ErrNoRows {
+		return nil, &executionError{Status: http.StatusNotFound, Code: "not_found", Message: "Item not found"}
+	}
+	if err != nil {
+		return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to fetch item"}
+	}
+
+	switch itemType {
+	case "request":
+		item, execErr := h.fetchExecutableItem(itemID, "request")
+		if execErr != nil {
+			return nil, execErr
+		}
+		return []models.CollectionItem{*item}, nil
+
+	case "folder":
+		flatItems, err := fetchCollectionItems(h.db, collectionID)
+		if err != nil {
+			return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to fetch collection items"}
+		}
+		requests := filterRunnableItems(flatItems, &itemID)
+		if len(requests) == 0 {
+			return nil, &executionError{Status: http.StatusBadRequest, Code: "empty_folder", Message: "Folder contains no requests"}
+		}
+		return requests, nil
+	}
+
+	return nil, &executionError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_item_type",
+		Message: "Only items of type 'request' or 'folder' can be load tested",
+	}
+}
+
+// newLoadTestClient builds the client shared by the virtual users of a load
+// test. Its transports keep enough idle connections for every user to reuse
+// its own. The returned function closes them.
+func (h *ExecutionHandler) newLoadTestClient(opts requestOptions, concurrency int) (*http.Client, func()) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConns = 0
+	base.MaxIdleConnsPerHost = concurrency
+
+	transport := newHostTransport(opts.tls, opts.proxy)
+	transport.base = base
+
+	client := &http.Client{
+		CheckRedirect: h.checkRedirect,
+		Transport:     transport,
+		Timeout:       h.cfg.RequestTimeout,
+	}
+	if opts.jar != nil {
+		client.Jar = opts.jar
+	}
+	return client, transport.CloseIdleConnections
+}
+
+// loadTest is a load test in progress
+type loadTest struct {
+	handler    *ExecutionHandler
+	client     *http.Client
+	limiter    *rate.Limiter // The caller's rate limit, if any
+	targets    []loadTestTarget
+	iterations int64 // Zero when the test is bounded by its duration
+	total      *loadTestStats
+	started    time.Time
+
+	claimed   atomic.Int64 // Iterations started
+	completed atomic.Int64 // Iterations finished
+	active    atomic.Int32 // Users started and not yet done
+}
+
+// loadTestTarget is a prepared request sent by a load test
+type loadTestTarget struct {
+	item     *models.CollectionItem
+	info     models.LoadTestItemInfo
+	prepared *preparedRequest
+	stats    *loadTestStats
+}
+
+// run starts the virtual users, spread over rampUp, and waits for them to finish
+func (t *loadTest) run(ctx context.Context, concurrency int, rampUp time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		delay := rampUp * time.Duration(i) / time.Duration(concurrency)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			t.active.Add(1)
+			defer t.active.Add(-1)
+			t.user(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// user runs iterations until the test is over. Requests interrupted by the
+// end of the test are not counted.
+func (t *loadTest) user(ctx context.Context) {
+	for ctx.Err() == nil {
+		if t.iterations > 0 && t.claimed.Add(1) > t.iterations {
+			return
+		}
+
+		for i := range t.targets {
+			target := &t.targets[i]
+			if t.limiter != nil {
+				if err := t.limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+
+			req := target.prepared.req
+			startTime := time.Now()
+			response, err := t.handler.sendRequest(ctx, t.client, req.Method, req.URL, req.Headers, req.Body, target.prepared.opts)
+			latency := time.Since(startTime)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				target.stats.recordError(latency, err.Error())
+				t.total.recordError(latency, err.Error())
+				continue
+			}
+			response.Assertions = evaluateAssertions(target.item.Assertions, response)
+			passed, _ := responsePassed(response)
+			target.stats.recordResponse(latency, response.Status, passed)
+			t.total.recordResponse(latency, response.Status, passed)
+		}
+		t.completed.Add(1)
+	}
+}
+
+func (t *loadTest) progress() models.LoadTestProgress {
+	elapsed := time.Since(t.started)
+	return models.LoadTestProgress{
+		ElapsedMs:           elapsed.Milliseconds(),
+		ActiveUsers:         int(t.active.Load()),
+		CompletedIterations: t.completed.Load(),
+		LoadTestStats:       t.total.summarize(elapsed),
+	}
+}
+
+func (t *loadTest) report() models.LoadTestReport {
+	elapsed := time.Since(t.started)
+	report := models.LoadTestReport{
+		DurationMs:          elapsed.Milliseconds(),
+		CompletedIterations: t.completed.Load(),
+		LoadTestStats:       t.total.summarize(elapsed),
+		Requests:            make([]models.LoadTestItemStats, 0, len(t.targets)),
+	}
+	for _, target := range t.targets {
+		report.Requests = append(report.Requests, models.LoadTestItemStats{
+			LoadTestItemInfo: target.info,
+			LoadTestStats:    target.stats.summarize(elapsed),
+		})
+	}
+	return report
+}
+
+// loadTestStats accumulates the outcomes of load test requests
+type loadTestStats struct {
+	mu            sync.Mutex
+	latencies     []time.Duration // One per request
+	errors        int64
+	failures      int64
+	statusCodes   map[int]int64
+	errorMessages map[string]int64
+}
+
+func newLoadTestStats() *loadTestStats {
+	return &loadTestStats{statusCodes: make(map[int]int64), errorMessages: make(map[string]int64)}
+}
+
+// recordError counts a request that did not complete
+func (s *loadTestStats) recordError(latency time.Duration, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, latency)
+	s.errors++
+	if _, ok := s.errorMessages[message]; ok || len(s.errorMessages) < loadTestMaxErrorMessages {
+		s.errorMessages[message]++
+	}
+}
+
+// recordResponse counts a completed request
+func (s *loadTestStats) recordResponse(latency time.Duration, status int, passed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, latency)
+	s.statusCodes[status]++
+	if !passed {
+		s.failures++
+	}
+}
+
+// summarize reports the statistics of the requests so far, elapsed into the test
+func (s *loadTestStats) summarize(elapsed time.Duration) models.LoadTestStats {
+	s.mu.Lock()
+	latencies := slices.Clone(s.latencies)
+	stats := models.LoadTestStats{
+		Requests:    int64(len(latencies)),
+		Errors:      s.errors,
+		Failures:    s.failures,
+		StatusCodes: make(map[int]int64, len(s.statusCodes)),
+	}
+	for status, count := range s.statusCodes {
+		stats.StatusCodes[status] = count
+	}
+	if len(s.errorMessages) > 0 {
+		stats.ErrorMessages = make(map[string]int64, len(s.errorMessages))
+		for message, count := range s.errorMessages {
+			stats.ErrorMessages[message] = count
+		}
+	}
+	s.mu.Unlock()
+
+	if stats.Requests == 0 {
+		return stats
+	}
+	stats.ErrorRate = roundFloat(float64(stats.Errors+stats.Failures) / float64(stats.Requests))
+	if elapsed > 0 {
+		stats.ThroughputRPS = roundFloat(float64(stats.Requests) / elapsed.Seconds())
+	}
+
+	slices.Sort(latencies)
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	stats.Latency = models.LoadTestLatency{
+		Min:  durationMillis(latencies[0]),
+		Mean: durationMillis(sum / time.Duration(len(latencies))),
+		Max:  durationMillis(latencies[len(latencies)-1]),
+		P50:  durationMillis(percentile(latencies, 50)),
+		P90:  durationMillis(percentile(latencies, 90)),
+		P95:  durationMillis(percentile(latencies, 95)),
+		P99:  durationMillis(percentile(latencies, 99)),
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted, which must not be empty
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// durationMillis converts d to milliseconds with microsecond precision
+func durationMillis(d time.Duration) float64 {
+	return roundFloat(float64(d) / float64(time.Millisecond))
+}
+
+// roundFloat rounds v to three decimals
+func roundFloat(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
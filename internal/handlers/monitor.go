@@ -0,0 +1,580 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"postman-runner/internal/config"
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron/v3"
+)
+
+// monitorMinInterval is the shortest time allowed between two monitor runs
+const monitorMinInterval = time.Minute
+
+// Limits on the number of runs listed by GetMonitorResults
+const (
+	monitorDefaultResults = 20
+	monitorMaxResults     = 100
+)
+
+type MonitorHandler struct {
+	db  *sql.DB
+	cfg *config.Config
+}
+
+func NewMonitorHandler(db *sql.DB, cfg *config.Config) *MonitorHandler {
+	return &MonitorHandler{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateMonitorRequest represents the request body for creating a monitor.
+// The cron expression has five fields or is a descriptor such as @hourly,
+// evaluated in the IANA timezone given (UTC by default).
+type CreateMonitorRequest struct {
+	Name           string `json:"name" binding:"required"`
+	CollectionID   int    `json:"collection_id" binding:"required"`
+	FolderID       *int   `json:"folder_id"`
+	EnvironmentID  *int   `json:"environment_id"`
+	CronExpression string `json:"cron_expression" binding:"required"`
+	Timezone       string `json:"timezone"`
+	Enabled        *bool  `json:"enabled"`
+	StopOnFailure  bool   `json:"stop_on_failure"`
+}
+
+// UpdateMonitorRequest represents the request body for updating a monitor.
+// The collection and folder a monitor runs cannot be changed.
+type UpdateMonitorRequest struct {
+	Name           *string `json:"name"`
+	EnvironmentID  *int    `json:"environment_id"`
+	CronExpression *string `json:"cron_expression"`
+	Timezone       *string `json:"timezone"`
+	Enabled        *bool   `json:"enabled"`
+	StopOnFailure  *bool   `json:"stop_on_failure"`
+}
+
+// monitorSchedule is a parsed cron expression and the timezone it is evaluated in
+type monitorSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// parseMonitorSchedule checks a monitor's cron expression and timezone
+func parseMonitorSchedule(expression, timezone string) (*monitorSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		return nil, fmt.Errorf("cron_expression must not set a timezone; use the timezone field")
+	}
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron_expression: %v", err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" || timezone == "Local" {
+		return nil, fmt.Errorf("invalid timezone %q", timezone)
+	}
+
+	s := &monitorSchedule{schedule: schedule, location: location}
+	first := s.next(time.Now())
+	if first.IsZero() {
+		return nil, fmt.Errorf("cron_expression never matches")
+	}
+	if s.next(first).Sub(first) < monitorMinInterval {
+		return nil, fmt.Errorf("monitors may run at most once every %v", monitorMinInterval)
+	}
+	return s, nil
+}
+
+// next returns the first scheduled time after t, in UTC
+func (s *monitorSchedule) next(t time.Time) time.Time {
+	next := s.schedule.Next(t.In(s.location))
+	if next.IsZero() {
+		return next
+	}
+	return next.UTC()
+}
+
+// validateMonitorTarget checks that the folder, if any, is a folder of the
+// collection and that the environment, if any, exists
+func (h *MonitorHandler) validateMonitorTarget(collectionID int, folderID, environmentID *int) *executionError {
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE id = $1)", collectionID).Scan(&exists)
+	if err != nil {
+		return &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to check collection existence"}
+	}
+	if !exists {
+		return &executionError{Status: http.StatusBadRequest, Code: "invalid_collection", Message: "Collection not found"}
+	}
+
+	if folderID != nil {
+		err := h.db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM collection_items WHERE id = $1 AND collection_id = $2 AND item_type = 'folder')
+		`, *folderID, collectionID).Scan(&exists)
+		if err != nil {
+			return &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to check folder existence"}
+		}
+		if !exists {
+			return &executionError{Status: http.StatusBadRequest, Code: "invalid_folder", Message: "Folder not found in collection"}
+		}
+	}
+
+	if environmentID != nil {
+		err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM environments WHERE id = $1)", *environmentID).Scan(&exists)
+		if err != nil {
+			return &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to check environment existence"}
+		}
+		if !exists {
+			return &executionError{Status: http.StatusBadRequest, Code: "invalid_environment", Message: "Environment not found"}
+		}
+	}
+
+	return nil
+}
+
+const monitorColumns = `id, name, collection_id, folder_id, environment_id, cron_expression, timezone, enabled,
+	stop_on_failure, next_run_at, last_run_at, last_error, created_at, updated_at`
+
+func scanMonitor(row rowScanner) (*models.Monitor, error) {
+	var monitor models.Monitor
+	var folderID, environmentID sql.NullInt64
+	var nextRunAt, lastRunAt sql.NullTime
+	var lastError sql.NullString
+	err := row.Scan(
+		&monitor.ID,
+		&monitor.Name,
+		&monitor.CollectionID,
+		&folderID,
+		&environmentID,
+		&monitor.CronExpression,
+		&monitor.Timezone,
+		&monitor.Enabled,
+		&monitor.StopOnFailure,
+		&nextRunAt,
+		&lastRunAt,
+		&lastError,
+		&monitor.CreatedAt,
+		&monitor.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	monitor.FolderID = nullIntPtr(folderID)
+	monitor.EnvironmentID = nullIntPtr(environmentID)
+	if nextRunAt.Valid {
+		monitor.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		monitor.LastRunAt = &lastRunAt.Time
+	}
+	monitor.LastError = lastError.String
+	return &monitor, nil
+}
+
+// nextRunAt returns the time a monitor should next run, or nil when disabled
+func nextRunAt(schedule *monitorSchedule, enabled bool) *time.Time {
+	if !enabled {
+		return nil
+	}
+	next := schedule.next(time.Now())
+	return &next
+}
+
+// CreateMonitor handles POST /monitors
+func (h *MonitorHandler) CreateMonitor(c *gin.Context) {
+	var req CreateMonitorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Name, collection_id and cron_expression are required",
+		})
+		return
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	schedule, err := parseMonitorSchedule(req.CronExpression, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_schedule",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if execErr := h.validateMonitorTarget(req.CollectionID, req.FolderID, req.EnvironmentID); execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+	row := h.db.QueryRow(`
+		INSERT INTO monitors (name, collection_id, folder_id, environment_id, cron_expression, timezone, enabled, stop_on_failure, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+monitorColumns,
+		req.Name, req.CollectionID, req.FolderID, req.EnvironmentID, strings.TrimSpace(req.CronExpression),
+		req.Timezone, enabled, req.StopOnFailure, nextRunAt(schedule, enabled))
+	monitor, err := scanMonitor(row)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create monitor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, monitor)
+}
+
+// ListMonitors handles GET /monitors. The optional collection_id query
+// parameter limits the list to the monitors of that collection.
+func (h *MonitorHandler) ListMonitors(c *gin.Context) {
+	query := `SELECT ` + monitorColumns + ` FROM monitors`
+	args := []interface{}{}
+	if collectionIDStr := c.Query("collection_id"); collectionIDStr != "" {
+		collectionID, err := strconv.Atoi(collectionIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_id",
+				Message: "collection_id must be a valid integer",
+			})
+			return
+		}
+		query += ` WHERE collection_id = $1`
+		args = append(args, collectionID)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch monitors",
+		})
+		return
+	}
+	defer rows.Close()
+
+	monitors := []models.Monitor{}
+	for rows.Next() {
+		monitor, err := scanMonitor(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan monitor",
+			})
+			return
+		}
+		monitors = append(monitors, *monitor)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"monitors": monitors,
+	})
+}
+
+// parseMonitorID reads the monitor ID path parameter, writing an error
+// response if it is invalid
+func parseMonitorID(c *gin.Context) (int, bool) {
+	monitorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Monitor ID must be a valid integer",
+		})
+		return 0, false
+	}
+	return monitorID, true
+}
+
+// GetMonitor handles GET /monitors/:id
+func (h *MonitorHandler) GetMonitor(c *gin.Context) {
+	monitorID, ok := parseMonitorID(c)
+	if !ok {
+		return
+	}
+
+	// Locked so that a run claimed by the scheduler meanwhile does not have
+	// its next_run_at overwritten with the one read here
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	monitor, err := scanMonitor(tx.QueryRow(`SELECT `+monitorColumns+` FROM monitors WHERE id = $1 FOR UPDATE`, monitorID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Monitor not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch monitor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, monitor)
+}
+
+// UpdateMonitor handles PUT /monitors/:id. The next run is rescheduled when
+// the schedule changes or the monitor is enabled.
+func (h *MonitorHandler) UpdateMonitor(c *gin.Context) {
+	monitorID, ok := parseMonitorID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMonitorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_json",
+			Message: "Invalid JSON format",
+		})
+		return
+	}
+
+	// Locked so that a run claimed by the scheduler meanwhile does not have
+	// its next_run_at overwritten with the one read here
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	monitor, err := scanMonitor(tx.QueryRow(`SELECT `+monitorColumns+` FROM monitors WHERE id = $1 FOR UPDATE`, monitorID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Monitor not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch monitor",
+		})
+		return
+	}
+
+	reschedule := false
+	if req.Name != nil {
+		monitor.Name = *req.Name
+	}
+	if req.EnvironmentID != nil {
+		monitor.EnvironmentID = req.EnvironmentID
+	}
+	if req.CronExpression != nil {
+		monitor.CronExpression = strings.TrimSpace(*req.CronExpression)
+		reschedule = true
+	}
+	if req.Timezone != nil {
+		monitor.Timezone = *req.Timezone
+		reschedule = true
+	}
+	if req.Enabled != nil {
+		reschedule = reschedule || *req.Enabled != monitor.Enabled
+		monitor.Enabled = *req.Enabled
+	}
+	if req.StopOnFailure != nil {
+		monitor.StopOnFailure = *req.StopOnFailure
+	}
+
+	schedule, err := parseMonitorSchedule(monitor.CronExpression, monitor.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_schedule",
+			Message: err.Error(),
+		})
+		return
+	}
+	if execErr := h.validateMonitorTarget(monitor.CollectionID, monitor.FolderID, monitor.EnvironmentID); execErr != nil {
+		execErr.respond(c)
+		return
+	}
+	if reschedule {
+		monitor.NextRunAt = nextRunAt(schedule, monitor.Enabled)
+	}
+
+	row := tx.QueryRow(`
+		UPDATE monitors
+		SET name = $1, environment_id = $2, cron_expression = $3, timezone = $4, enabled = $5,
+			stop_on_failure = $6, next_run_at = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING `+monitorColumns,
+		monitor.Name, monitor.EnvironmentID, monitor.CronExpression, monitor.Timezone, monitor.Enabled,
+		monitor.StopOnFailure, monitor.NextRunAt, monitorID)
+	monitor, err = scanMonitor(row)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Monitor not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update monitor",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, monitor)
+}
+
+// DeleteMonitor handles DELETE /monitors/:id. Runs of the monitor are kept.
+func (h *MonitorHandler) DeleteMonitor(c *gin.Context) {
+	monitorID, ok := parseMonitorID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec(`DELETE FROM monitors WHERE id = $1`, monitorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to delete monitor",
+		})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Monitor not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Monitor deleted successfully",
+	})
+}
+
+// GetMonitorResults handles GET /monitors/:id/results. The optional limit
+// query parameter sets how many of the latest runs are listed; the uptime
+// is the share of those that passed, not counting runs still in progress or
+// cancelled.
+func (h *MonitorHandler) GetMonitorResults(c *gin.Context) {
+	monitorID, ok := parseMonitorID(c)
+	if !ok {
+		return
+	}
+
+	limit := monitorDefaultResults
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > monitorMaxResults {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_limit",
+				Message: fmt.Sprintf("limit must be between 1 and %d", monitorMaxResults),
+			})
+			return
+		}
+	}
+
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM monitors WHERE id = $1)", monitorID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check monitor existence",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Monitor not found",
+		})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, status, total_requests, passed_requests, failed_requests, duration_ms, started_at, finished_at
+		FROM runs
+		WHERE monitor_id = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2
+	`, monitorID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch monitor runs",
+		})
+		return
+	}
+	defer rows.Close()
+
+	results := models.MonitorResults{MonitorID: monitorID, Runs: []models.MonitorRun{}}
+	finished, passed := 0, 0
+	for rows.Next() {
+		var run models.MonitorRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(
+			&run.RunID,
+			&run.Status,
+			&run.TotalRequests,
+			&run.PassedRequests,
+			&run.FailedRequests,
+			&run.DurationMs,
+			&run.StartedAt,
+			&finishedAt,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan monitor run",
+			})
+			return
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+
+		if run.Status == "passed" || run.Status == "failed" {
+			finished++
+			if run.Status == "passed" {
+				passed++
+			}
+		}
+		results.Runs = append(results.Runs, run)
+	}
+
+	if finished > 0 {
+		uptime := roundFloat(float64(passed) * 100 / float64(finished))
+		results.UptimePercent = &uptime
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
+)
+
+const (
+	oauth2ClientCredentials = "client_credentials"
+
+	// Tokens are refreshed this long before they expire
+	oauth2ExpiryMargin = 30 * time.Second
+	// Lifetime assumed for tokens issued without expires_in
+	oauth2DefaultLifetime = 5 * time.Minute
+	// Upper bound on the size of a token endpoint response
+	oauth2MaxResponseSize = 1 << 20
+)
+
+type oauth2Token struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// oauth2TokenCache keeps client-credentials tokens until shortly before they
+// expire so that runs do not request a new token for every item
+type oauth2TokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]oauth2Token
+}
+
+var oauth2Tokens = &oauth2TokenCache{tokens: make(map[string]oauth2Token)}
+
+func (c *oauth2TokenCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, ok := c.tokens[key]
+	if !ok || time.Now().After(token.expiresAt) {
+		delete(c.tokens, key)
+		return "", false
+	}
+	return token.accessToken, true
+}
+
+func (c *oauth2TokenCache) put(key, accessToken string, lifetime time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[key] = oauth2Token{accessToken: accessToken, expiresAt: time.Now().Add(lifetime - oauth2ExpiryMargin)}
+}
+
+func (c *oauth2TokenCache) evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tokens, key)
+}
+
+// oauth2CacheKey identifies a token by everything that was used to obtain it.
+// The key is hashed so that client secrets are not kept in plain text.
+func oauth2CacheKey(params map[string]string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		params["accessTokenUrl"], params["clientId"], params["clientSecret"], params["scope"], params["audience"],
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// usesClientCredentials reports whether the runner has to obtain the token
+// itself rather than use a token stored in the auth attributes
+func usesClientCredentials(params map[string]string) bool {
+	return params["grant_type"] == oauth2ClientCredentials || params["accessToken"] == ""
+}
+
+// oauth2AccessToken returns the token to send for an oauth2 auth, fetching a
+// client-credentials token if there is no valid one in the cache
+func (h *ExecutionHandler) oauth2AccessToken(ctx context.Context, auth *models.Auth, opts requestOptions) (string, *executionError) {
+	if !usesClientCredentials(auth.Params) {
+		return auth.Params["accessToken"], nil
+	}
+
+	key := oauth2CacheKey(auth.Params)
+	if token, ok := oauth2Tokens.get(key); ok {
+		return token, nil
+	}
+
+	tokenURL := auth.Params["accessTokenUrl"]
+	if err := validator.ValidateExecutionURL(tokenURL, h.cfg.AllowLocalhost, h.cfg.AllowPrivateIPs); err != nil {
+		return "", &executionError{
+			Status:  http.StatusForbidden,
+			Code:    "ssrf_protection",
+			Message: fmt.Sprintf("OAuth 2.0 token URL blocked by SSRF protection: %v", err),
+		}
+	}
+
+	token, lifetime, err := h.requestClientCredentialsToken(ctx, tokenURL, auth.Params, opts)
+	if err != nil {
+		return "", &executionError{
+			Status:  http.StatusBadGateway,
+			Code:    "auth_error",
+			Message: fmt.Sprintf("Failed to obtain OAuth 2.0 access token: %v", err),
+		}
+	}
+
+	oauth2Tokens.put(key, token, lifetime)
+	return token, nil
+}
+
+// requestClientCredentialsToken performs the client credentials grant (RFC
+// 6749 section 4.4) and returns the access token and its lifetime. The token
+// endpoint is reached with the same certificates and proxy as the request.
+func (h *ExecutionHandler) requestClientCredentialsToken(ctx context.Context, tokenURL string, params map[string]string, opts requestOptions) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", oauth2ClientCredentials)
+	if params["scope"] != "" {
+		form.Set("scope", params["scope"])
+	}
+	if params["audience"] != "" {
+		form.Set("audience", params["audience"])
+	}
+
+	// Credentials go in a Basic header unless Postman was set to send them in the body
+	sendInBody := params["client_authentication"] == "body"
+	if sendInBody {
+		form.Set("client_id", params["clientId"])
+		form.Set("client_secret", params["clientSecret"])
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !sendInBody {
+		req.Header.Set("Authorization", basicAuthorization(url.QueryEscape(params["clientId"]), url.QueryEscape(params["clientSecret"])))
+	}
+
+	// Redirects are not followed so the credentials cannot be sent elsewhere
+	client := &http.Client{
+		Timeout: h.cfg.RequestTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if transport := opts.newTransport(); transport != nil {
+		client.Transport = transport
+		defer transport.CloseIdleConnections()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oauth2MaxResponseSize))
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", 0, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	accessToken, expiresIn, err := parseTokenResponse(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	lifetime := oauth2DefaultLifetime
+	if expiresIn > 0 {
+		lifetime = time.Duration(expiresIn) * time.Second
+	}
+	return accessToken, lifetime, nil
+}
+
+// parseTokenResponse reads a token endpoint response, which is JSON per the
+// spec but form-encoded with some providers
+func parseTokenResponse(contentType string, body []byte) (string, int64, error) {
+	var accessToken, expiresIn string
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "text/plain" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid token response: %w", err)
+		}
+		accessToken = values.Get("access_token")
+		expiresIn = values.Get("expires_in")
+	} else {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return "", 0, fmt.Errorf("token response is not valid JSON")
+		}
+		accessToken, _ = payload["access_token"].(string)
+		if v, ok := payload["expires_in"]; ok && v != nil {
+			expiresIn = jsonValueToString(v)
+		}
+	}
+
+	if accessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	seconds, _ := strconv.ParseInt(expiresIn, 10, 64)
+	return accessToken, seconds, nil
+}
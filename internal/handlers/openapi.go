@@ -0,0 +1,890 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+// openAPIMethods lists the operations of a path item in the order they are imported
+var openAPIMethods = []string{"get", "put", "post", "patch", "delete", "head", "options", "trace"}
+
+// openAPIPathParam matches the {name} placeholders of a path template
+var openAPIPathParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// maxSchemaRefDepth bounds the $refs followed while building an example, so
+// that recursive schemas stay small
+const maxSchemaRefDepth = 16
+
+// maxSchemaRefExpansions bounds the $refs expanded, reused ones included,
+// while building one example, so that schemas referencing each other many
+// times over cannot take exponential time or produce exponential output
+const maxSchemaRefExpansions = 10000
+
+// ImportOpenAPI handles POST /collections/upload/openapi. The body is an
+// OpenAPI 3.0/3.1 or Swagger 2.0 document in JSON or YAML. It becomes a
+// collection with a folder per tag and a request per operation, and an
+// environment of the same name seeding baseUrl from the servers and the path
+// parameters from their examples.
+func (h *CollectionHandler) ImportOpenAPI(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, h.cfg.MaxRequestSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "read_error",
+			Message: "Failed to read request body",
+		})
+		return
+	}
+
+	doc, err := parseOpenAPIDocument(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	items, variables := doc.convert()
+
+	variablesJSON, err := json.Marshal(variables)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "json_error",
+			Message: "Failed to encode variables",
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	name := truncateItemName(doc.title())
+	description := specString(specObject(doc.root["info"])["description"])
+
+	var collectionID int
+	err = tx.QueryRow(`
+		INSERT INTO collections (name, description)
+		VALUES ($1, $2)
+		RETURNING id
+	`, name, description).Scan(&collectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create collection",
+		})
+		return
+	}
+
+	if err := h.importItems(tx, collectionID, 0, items, 0, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "import_error",
+			Message: fmt.Sprintf("Failed to import items: %v", err),
+		})
+		return
+	}
+
+	var environmentID int
+	err = tx.QueryRow(`
+		INSERT INTO environments (name, description, variables)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, name, "Variables of the imported OpenAPI document", variablesJSON).Scan(&environmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create environment",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"collection_id":  collectionID,
+		"environment_id": environmentID,
+		"message":        "OpenAPI document imported successfully",
+	})
+}
+
+// openAPIDocument is a decoded OpenAPI 3.x or Swagger 2.0 document
+type openAPIDocument struct {
+	root      map[string]interface{}
+	swagger   bool     // Swagger 2.0 rather than OpenAPI 3.x
+	pathOrder []string // Keys of paths in document order
+
+	examples  map[string]cachedExample // Examples of $refs, where they do not depend on the enclosing schemas
+	expanded  int                      // $refs expanded for the current example or form
+	truncated int                      // $refs left out as recursive or too deep, over all examples
+}
+
+// cachedExample is the example of a $ref, and the number of $refs expanded
+// to build it which is charged again whenever it is reused
+type cachedExample struct {
+	value    interface{}
+	expanded int
+}
+
+// parseOpenAPIDocument decodes a JSON or YAML document and checks that it is
+// an OpenAPI 3.x or Swagger 2.0 specification with paths
+func parseOpenAPIDocument(data []byte) (*openAPIDocument, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("document is empty")
+	}
+	if trimmed[0] != '{' {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		data = converted
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil || root == nil {
+		return nil, fmt.Errorf("document must be a JSON or YAML object")
+	}
+
+	doc := &openAPIDocument{root: root, examples: make(map[string]cachedExample)}
+	switch {
+	case strings.HasPrefix(specString(root["openapi"]), "3."):
+	case specString(root["swagger"]) == "2.0":
+		doc.swagger = true
+	default:
+		return nil, fmt.Errorf("document must be an OpenAPI 3.x or Swagger 2.0 specification")
+	}
+
+	if len(specObject(root["paths"])) == 0 {
+		return nil, fmt.Errorf("document defines no paths")
+	}
+	order, err := pathOrder(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paths: %v", err)
+	}
+	doc.pathOrder = order
+	return doc, nil
+}
+
+// pathOrder returns the keys of the top-level paths object of a JSON
+// document in the order they appear, which decoding into a map loses
+func pathOrder(data []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "paths" {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if delim, err := decoder.Token(); err != nil || delim != json.Delim('{') {
+			return nil, fmt.Errorf("paths must be an object")
+		}
+		var keys []string
+		for decoder.More() {
+			path, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, path.(string))
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+		return keys, nil
+	}
+	return nil, nil
+}
+
+// title names the collection and environment created from the document
+func (d *openAPIDocument) title() string {
+	if title := strings.TrimSpace(specString(specObject(d.root["info"])["title"])); title != "" {
+		return title
+	}
+	return "Imported API"
+}
+
+// convert turns the operations of the document into Postman items, with a
+// folder per tag, and returns them with the variables to seed
+func (d *openAPIDocument) convert() ([]models.PostmanItem, map[string]string) {
+	variables := make(map[string]string)
+	if baseURL := d.baseURL(); baseURL != "" {
+		variables["baseUrl"] = baseURL
+	}
+
+	// Declared tags come first, in the order of the document; others in
+	// the order of the first operation using them
+	var tagOrder []string
+	tagged := make(map[string][]models.PostmanItem)
+	for _, tag := range specArray(d.root["tags"]) {
+		name := specString(specObject(tag)["name"])
+		if _, seen := tagged[name]; name != "" && !seen {
+			tagOrder = append(tagOrder, name)
+			tagged[name] = nil
+		}
+	}
+	var untagged []models.PostmanItem
+
+	paths := specObject(d.root["paths"])
+	for _, path := range d.pathOrder {
+		pathItem := d.resolve(paths[path])
+		for _, method := range openAPIMethods {
+			operation := specObject(pathItem[method])
+			if operation == nil {
+				continue
+			}
+
+			item := models.PostmanItem{
+				Name:    operationName(operation, method, path),
+				Request: d.request(path, method, pathItem, operation, variables),
+			}
+
+			tag := ""
+			if tags := specArray(operation["tags"]); len(tags) > 0 {
+				tag = specString(tags[0])
+			}
+			if tag == "" {
+				untagged = append(untagged, item)
+				continue
+			}
+			if _, seen := tagged[tag]; !seen {
+				tagOrder = append(tagOrder, tag)
+			}
+			tagged[tag] = append(tagged[tag], item)
+		}
+	}
+
+	var items []models.PostmanItem
+	for _, tag := range tagOrder {
+		if len(tagged[tag]) > 0 {
+			items = append(items, models.PostmanItem{Name: truncateItemName(tag), Item: tagged[tag]})
+		}
+	}
+	return append(items, untagged...), variables
+}
+
+// baseURL returns the URL of the first server of the document, or of its
+// host, schemes and basePath for Swagger 2.0
+func (d *openAPIDocument) baseURL() string {
+	if d.swagger {
+		host := specString(d.root["host"])
+		basePath := strings.TrimSuffix(specString(d.root["basePath"]), "/")
+		if host == "" {
+			return basePath
+		}
+		scheme := "https"
+		if schemes := specArray(d.root["schemes"]); len(schemes) > 0 {
+			scheme = specString(schemes[0])
+			for _, s := range schemes {
+				if specString(s) == "https" {
+					scheme = "https"
+				}
+			}
+		}
+		return scheme + "://" + host + basePath
+	}
+
+	servers := specArray(d.root["servers"])
+	if len(servers) == 0 {
+		return ""
+	}
+	server := specObject(servers[0])
+	serverVariables := specObject(server["variables"])
+	serverURL := openAPIPathParam.ReplaceAllStringFunc(specString(server["url"]), func(placeholder string) string {
+		variable := specObject(serverVariables[placeholder[1:len(placeholder)-1]])
+		if variable == nil {
+			return placeholder
+		}
+		return jsonValueToString(variable["default"])
+	})
+	return strings.TrimSuffix(serverURL, "/")
+}
+
+// operationName names the item of an operation after its summary or ID
+func operationName(operation map[string]interface{}, method, path string) string {
+	name := strings.TrimSpace(specString(operation["summary"]))
+	if name == "" {
+		name = strings.TrimSpace(specString(operation["operationId"]))
+	}
+	if name == "" {
+		name = strings.ToUpper(method) + " " + path
+	}
+	return truncateItemName(name)
+}
+
+// request builds the request of an operation. Path parameters become
+// {{variables}}, seeded from their examples; required query and header
+// parameters are sent with their example, or a {{variable}} without one.
+func (d *openAPIDocument) request(path, method string, pathItem, operation map[string]interface{}, variables map[string]string) *models.PostmanRequest {
+	req := &models.PostmanRequest{
+		Method: strings.ToUpper(method),
+		Header: []models.PostmanHeader{},
+	}
+
+	var query []string
+	var bodyParam map[string]interface{}
+	var formParams []map[string]interface{}
+	for _, param := range d.parameters(pathItem, operation) {
+		name := specString(param["name"])
+		example, hasExample := d.parameterExample(param)
+		switch specString(param["in"]) {
+		case "path":
+			if _, seeded := variables[name]; hasExample && !seeded {
+				variables[name] = example
+			}
+		case "query":
+			if param["required"] != true {
+				continue
+			}
+			value := "{{" + name + "}}"
+			if hasExample {
+				value = url.QueryEscape(example)
+			}
+			query = append(query, url.QueryEscape(name)+"="+value)
+		case "header":
+			// These are described by the body and security of the operation
+			if param["required"] != true || strings.EqualFold(name, "Accept") ||
+				strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "Authorization") {
+				continue
+			}
+			value := "{{" + name + "}}"
+			if hasExample {
+				value = example
+			}
+			req.Header = append(req.Header, models.PostmanHeader{Key: name, Value: value})
+		case "body":
+			bodyParam = param
+		case "formData":
+			formParams = append(formParams, param)
+		}
+	}
+
+	rawURL := "{{baseUrl}}" + openAPIPathParam.ReplaceAllString(path, "{{$1}}")
+	if len(query) > 0 {
+		rawURL += "?" + strings.Join(query, "&")
+	}
+	req.URL = rawURL
+
+	var contentType string
+	if d.swagger {
+		req.Body, contentType = d.swaggerBody(operation, bodyParam, formParams)
+	} else {
+		req.Body, contentType = d.requestBody(operation)
+	}
+	if contentType != "" {
+		req.Header = append(req.Header, models.PostmanHeader{Key: "Content-Type", Value: contentType})
+	}
+	return req
+}
+
+// parameters returns the parameters of an operation, those of its path item
+// overridden by its own with the same name and location
+func (d *openAPIDocument) parameters(pathItem, operation map[string]interface{}) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := make(map[string]int)
+	for _, list := range [][]interface{}{specArray(pathItem["parameters"]), specArray(operation["parameters"])} {
+		for _, raw := range list {
+			param := d.resolve(raw)
+			if param == nil {
+				continue
+			}
+			key := specString(param["in"]) + ":" + specString(param["name"])
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// parameterExample returns the example of a parameter, or of its schema, as text
+func (d *openAPIDocument) parameterExample(param map[string]interface{}) (string, bool) {
+	if example, ok := param["example"]; ok {
+		return jsonValueToString(example), true
+	}
+	if example, ok := param["x-example"]; ok {
+		return jsonValueToString(example), true
+	}
+	if example, ok := firstExample(param["examples"]); ok {
+		return jsonValueToString(d.resolve(example)["value"]), true
+	}
+
+	// Swagger 2.0 declares the schema of non-body parameters inline
+	schema := param["schema"]
+	if d.swagger {
+		schema = param
+	}
+	resolved := d.resolve(schema)
+	for _, key := range []string{"example", "default"} {
+		if value, ok := resolved[key]; ok {
+			return jsonValueToString(value), true
+		}
+	}
+	if enum := specArray(resolved["enum"]); len(enum) > 0 {
+		return jsonValueToString(enum[0]), true
+	}
+	return "", false
+}
+
+// requestBody builds the body of an OpenAPI 3.x operation and returns the
+// content type to send with it
+func (d *openAPIDocument) requestBody(operation map[string]interface{}) (*models.PostmanBody, string) {
+	content := specObject(d.resolve(operation["requestBody"])["content"])
+	mediaType := preferredMediaType(content)
+	if mediaType == "" {
+		return nil, ""
+	}
+	media := specObject(content[mediaType])
+	schema := media["schema"]
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return &models.PostmanBody{Mode: models.BodyModeURLEncoded, URLEncoded: d.schemaFields(schema, false)}, ""
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return &models.PostmanBody{Mode: models.BodyModeFormData, FormData: d.schemaFields(schema, true)}, ""
+	}
+
+	example, ok := media["example"]
+	if !ok {
+		if named, found := firstExample(media["examples"]); found {
+			example, ok = d.resolve(named)["value"], true
+		}
+	}
+	if !ok {
+		example = d.example(schema)
+	}
+
+	if strings.Contains(mediaType, "*") {
+		mediaType = ""
+	}
+	return &models.PostmanBody{Mode: models.BodyModeRaw, Raw: exampleBody(example, mediaType)}, mediaType
+}
+
+// swaggerBody builds the body of a Swagger 2.0 operation from its body or
+// formData parameters and returns the content type to send with it
+func (d *openAPIDocument) swaggerBody(operation, bodyParam map[string]interface{}, formParams []map[string]interface{}) (*models.PostmanBody, string) {
+	consumes := specArray(operation["consumes"])
+	if len(consumes) == 0 {
+		consumes = specArray(d.root["consumes"])
+	}
+
+	if len(formParams) > 0 {
+		multipart := false
+		for _, mediaType := range consumes {
+			multipart = multipart || strings.HasPrefix(specString(mediaType), "multipart/")
+		}
+		var fields []models.PostmanParam
+		for _, param := range formParams {
+			field := models.PostmanParam{Key: specString(param["name"]), Type: "text"}
+			if specString(param["type"]) == "file" {
+				multipart = true
+				field.Type = "file"
+			} else {
+				field.Value, _ = d.parameterExample(param)
+			}
+			fields = append(fields, field)
+		}
+		if multipart {
+			return &models.PostmanBody{Mode: models.BodyModeFormData, FormData: fields}, ""
+		}
+		return &models.PostmanBody{Mode: models.BodyModeURLEncoded, URLEncoded: fields}, ""
+	}
+
+	if bodyParam == nil {
+		return nil, ""
+	}
+	mediaTypes := make(map[string]interface{}, len(consumes))
+	for _, mediaType := range consumes {
+		mediaTypes[specString(mediaType)] = nil
+	}
+	mediaType := preferredMediaType(mediaTypes)
+	if mediaType == "" || strings.Contains(mediaType, "*") {
+		mediaType = "application/json"
+	}
+
+	example, ok := bodyParam["x-example"]
+	if !ok {
+		example = d.example(bodyParam["schema"])
+	}
+	return &models.PostmanBody{Mode: models.BodyModeRaw, Raw: exampleBody(example, mediaType)}, mediaType
+}
+
+// schemaFields turns the properties of an object schema into form fields.
+// Binary properties become file fields when files are allowed.
+func (d *openAPIDocument) schemaFields(schema interface{}, files bool) []models.PostmanParam {
+	d.expanded = 0
+	properties := d.properties(schema, map[string]bool{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]models.PostmanParam, 0, len(names))
+	for _, name := range names {
+		property := d.resolve(properties[name])
+		if property["readOnly"] == true {
+			continue
+		}
+		format := specString(property["format"])
+		if files && (format == "binary" || format == "base64") {
+			fields = append(fields, models.PostmanParam{Key: name, Type: "file"})
+			continue
+		}
+		value := d.example(property)
+		if value == nil {
+			value = ""
+		}
+		fields = append(fields, models.PostmanParam{Key: name, Value: jsonValueToString(value), Type: "text"})
+	}
+	return fields
+}
+
+// properties collects the properties of an object schema, including those
+// of the schemas it combines with allOf
+func (d *openAPIDocument) properties(schema interface{}, visiting map[string]bool) map[string]interface{} {
+	resolved, release := d.enter(schema, visiting)
+	if resolved == nil {
+		return nil
+	}
+	defer release()
+
+	properties := make(map[string]interface{})
+	for _, part := range specArray(resolved["allOf"]) {
+		for name, property := range d.properties(part, visiting) {
+			properties[name] = property
+		}
+	}
+	for name, property := range specObject(resolved["properties"]) {
+		properties[name] = property
+	}
+	return properties
+}
+
+// example builds an example value for a schema
+func (d *openAPIDocument) example(schema interface{}) interface{} {
+	d.expanded = 0
+	return d.schemaExample(schema, map[string]bool{})
+}
+
+// schemaExample builds an example value for a schema, preferring the
+// examples, defaults and enum values it declares. Read-only properties are
+// left out since the example is a request body. The example of a $ref is
+// built once, unless parts of it were left out as recursive.
+func (d *openAPIDocument) schemaExample(schema interface{}, visiting map[string]bool) interface{} {
+	ref, _ := specObject(schema)["$ref"].(string)
+	if cached, ok := d.examples[ref]; ok && ref != "" {
+		if d.expanded+cached.expanded > maxSchemaRefExpansions {
+			d.truncated++
+			return nil
+		}
+		d.expanded += cached.expanded
+		return cached.value
+	}
+
+	truncated, expanded := d.truncated, d.expanded
+	resolved, release := d.enter(schema, visiting)
+	if resolved == nil {
+		return nil
+	}
+	defer release()
+
+	example := d.resolvedExample(resolved, visiting)
+	if ref != "" && d.truncated == truncated {
+		d.examples[ref] = cachedExample{value: example, expanded: d.expanded - expanded}
+	}
+	return example
+}
+
+// resolvedExample builds the example of a schema whose $ref is resolved
+func (d *openAPIDocument) resolvedExample(resolved map[string]interface{}, visiting map[string]bool) interface{} {
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := resolved[key]; ok {
+			return value
+		}
+	}
+	for _, key := range []string{"examples", "enum"} {
+		if values := specArray(resolved[key]); len(values) > 0 {
+			return values[0]
+		}
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := specArray(resolved[key]); len(options) > 0 {
+			return d.schemaExample(options[0], visiting)
+		}
+	}
+
+	if parts := specArray(resolved["allOf"]); len(parts) > 0 {
+		merged := make(map[string]interface{})
+		var first interface{}
+		for _, part := range parts {
+			value := d.schemaExample(part, visiting)
+			if object, ok := value.(map[string]interface{}); ok {
+				for name, property := range object {
+					merged[name] = property
+				}
+			} else if first == nil {
+				first = value
+			}
+		}
+		for name, value := range d.propertyExamples(resolved, visiting) {
+			merged[name] = value
+		}
+		if len(merged) == 0 && first != nil {
+			return first
+		}
+		return merged
+	}
+
+	switch schemaType(resolved) {
+	case "object":
+		return d.propertyExamples(resolved, visiting)
+	case "array":
+		if item := d.schemaExample(resolved["items"], visiting); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case "string":
+		return stringExample(specString(resolved["format"]))
+	case "integer", "number":
+		if minimum, ok := resolved["minimum"]; ok {
+			return minimum
+		}
+		return 0
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// propertyExamples builds the examples of the properties of an object
+// schema. Read-only properties, and those without an example because their
+// schema recurses, are left out.
+func (d *openAPIDocument) propertyExamples(schema map[string]interface{}, visiting map[string]bool) map[string]interface{} {
+	examples := make(map[string]interface{})
+	for name, property := range specObject(schema["properties"]) {
+		if d.resolve(property)["readOnly"] == true {
+			continue
+		}
+		if value := d.schemaExample(property, visiting); value != nil {
+			examples[name] = value
+		}
+	}
+	return examples
+}
+
+// enter resolves a schema while building an example. A $ref already being
+// expanded, nested too deeply or past the expansion budget yields nil. The
+// returned function must be called once the schema is done.
+func (d *openAPIDocument) enter(schema interface{}, visiting map[string]bool) (map[string]interface{}, func()) {
+	object := specObject(schema)
+	ref, isRef := object["$ref"].(string)
+	if !isRef {
+		return object, func() {}
+	}
+	if visiting[ref] || len(visiting) >= maxSchemaRefDepth || d.expanded >= maxSchemaRefExpansions {
+		d.truncated++
+		return nil, nil
+	}
+	d.expanded++
+	resolved := d.resolve(object)
+	if resolved == nil {
+		return nil, nil
+	}
+	visiting[ref] = true
+	return resolved, func() { delete(visiting, ref) }
+}
+
+// schemaType returns the type of a schema, inferring it from its keywords
+// when absent. Of the types of an OpenAPI 3.1 schema, the first besides
+// null is used.
+func schemaType(schema map[string]interface{}) string {
+	switch types := schema["type"].(type) {
+	case string:
+		return types
+	case []interface{}:
+		for _, t := range types {
+			if name := specString(t); name != "null" {
+				return name
+			}
+		}
+	}
+	if schema["properties"] != nil {
+		return "object"
+	}
+	if schema["items"] != nil {
+		return "array"
+	}
+	return ""
+}
+
+// stringExample returns an example string of a format
+func stringExample(format string) string {
+	switch format {
+	case "date":
+		return "1970-01-01"
+	case "date-time":
+		return "1970-01-01T00:00:00Z"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte", "binary":
+		return ""
+	}
+	return "string"
+}
+
+// exampleBody renders an example as the raw body of a media type. JSON is
+// indented; other media types take string examples as they are.
+func exampleBody(example interface{}, mediaType string) string {
+	if example == nil {
+		return ""
+	}
+	if text, ok := example.(string); ok && !strings.Contains(mediaType, "json") {
+		return text
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(example); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// preferredMediaType picks the media type to import from the keys of a
+// content map: JSON, then forms, then the first in alphabetical order
+func preferredMediaType(content map[string]interface{}) string {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	if len(mediaTypes) == 0 {
+		return ""
+	}
+
+	for _, prefer := range []func(string) bool{
+		func(t string) bool { return t == "application/json" },
+		func(t string) bool { return strings.HasSuffix(t, "+json") || strings.Contains(t, "json") },
+		func(t string) bool { return t == "application/x-www-form-urlencoded" },
+		func(t string) bool { return t == "multipart/form-data" },
+	} {
+		for _, mediaType := range mediaTypes {
+			if prefer(mediaType) {
+				return mediaType
+			}
+		}
+	}
+	return mediaTypes[0]
+}
+
+// firstExample returns the example of an OpenAPI 3.x examples map that comes
+// first in alphabetical order
+func firstExample(examples interface{}) (interface{}, bool) {
+	named := specObject(examples)
+	if len(named) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return named[names[0]], true
+}
+
+// resolve returns the object a value stands for, following $refs within the
+// document. References to other documents, and cycles of references, yield nil.
+func (d *openAPIDocument) resolve(value interface{}) map[string]interface{} {
+	object := specObject(value)
+	for hops := 0; object != nil; hops++ {
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object
+		}
+		if hops == maxSchemaRefDepth || !strings.HasPrefix(ref, "#/") {
+			return nil
+		}
+
+		var node interface{} = d.root
+		for _, token := range strings.Split(ref[2:], "/") {
+			if unescaped, err := url.PathUnescape(token); err == nil {
+				token = unescaped
+			}
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			node = specObject(node)[token]
+		}
+		object = specObject(node)
+	}
+	return nil
+}
+
+// specObject returns value as an object, or nil if it is not one
+func specObject(value interface{}) map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	return object
+}
+
+// specArray returns value as an array, or nil if it is not one
+func specArray(value interface{}) []interface{} {
+	array, _ := value.([]interface{})
+	return array
+}
+
+// specString returns value as a string, or "" if it is not one
+func specString(value interface{}) string {
+	str, _ := value.(string)
+	return str
+}
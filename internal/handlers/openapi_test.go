@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"postman-runner/internal/models"
+)
+
+func TestOpenAPIRequestBody(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		raw  string // Expected JSON body, compared after decoding
+		form []models.PostmanParam
+		mode string
+	}{
+		{
+			name: "recursive $ref",
+			doc: `{"openapi": "3.0.0", "paths": {"/nodes": {"post": {"requestBody": {"content": {"application/json": {
+				"schema": {"$ref": "#/components/schemas/Node"}}}}}}},
+				"components": {"schemas": {"Node": {"type": "object", "properties": {
+					"name": {"type": "string", "example": "root"},
+					"children": {"type": "array", "items": {"$ref": "#/components/schemas/Node"}}}}}}}`,
+			mode: models.BodyModeRaw,
+			raw:  `{"name": "root", "children": []}`,
+		},
+		{
+			name: "allOf merging",
+			doc: `{"openapi": "3.0.0", "paths": {"/pets": {"post": {"requestBody": {"content": {"application/json": {
+				"schema": {"allOf": [
+					{"$ref": "#/components/schemas/Base"},
+					{"type": "object", "properties": {"name": {"type": "string", "example": "Rex"}}}
+				], "properties": {"age": {"type": "integer", "minimum": 1}}}}}}}}},
+				"components": {"schemas": {"Base": {"type": "object", "properties": {
+					"id": {"type": "integer", "readOnly": true},
+					"kind": {"type": "string", "enum": ["dog", "cat"]}}}}}}`,
+			mode: models.BodyModeRaw,
+			raw:  `{"kind": "dog", "name": "Rex", "age": 1}`,
+		},
+		{
+			name: "Swagger 2 formData",
+			doc: `{"swagger": "2.0", "consumes": ["application/x-www-form-urlencoded"], "paths": {"/login": {"post": {"parameters": [
+				{"in": "formData", "name": "user", "type": "string", "x-example": "alice"},
+				{"in": "formData", "name": "remember", "type": "boolean", "default": true}]}}}}`,
+			mode: models.BodyModeURLEncoded,
+			form: []models.PostmanParam{
+				{Key: "user", Value: "alice", Type: "text"},
+				{Key: "remember", Value: "true", Type: "text"},
+			},
+		},
+		{
+			name: "Swagger 2 formData with a file",
+			doc: `{"swagger": "2.0", "paths": {"/upload": {"post": {"parameters": [
+				{"in": "formData", "name": "file", "type": "file"},
+				{"in": "formData", "name": "note", "type": "string"}]}}}}`,
+			mode: models.BodyModeFormData,
+			form: []models.PostmanParam{
+				{Key: "file", Type: "file"},
+				{Key: "note", Type: "text"},
+			},
+		},
+		{
+			name: "non-local $ref",
+			doc: `{"openapi": "3.0.0", "paths": {"/pets": {"post": {"requestBody": {"content": {"application/json": {
+				"schema": {"$ref": "other.yaml#/components/schemas/Pet"}}}}}}}}`,
+			mode: models.BodyModeRaw,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseOpenAPIDocument([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			items, _ := doc.convert()
+			if len(items) != 1 || items[0].Request == nil || items[0].Request.Body == nil {
+				t.Fatalf("expected one request with a body, got %+v", items)
+			}
+			body := items[0].Request.Body
+
+			if body.Mode != tt.mode {
+				t.Errorf("mode = %q, want %q", body.Mode, tt.mode)
+			}
+			if tt.raw != "" {
+				var got, want interface{}
+				if err := json.Unmarshal([]byte(body.Raw), &got); err != nil {
+					t.Fatalf("body %q is not JSON: %v", body.Raw, err)
+				}
+				if err := json.Unmarshal([]byte(tt.raw), &want); err != nil {
+					t.Fatalf("bad test case: %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("body = %s, want %s", body.Raw, tt.raw)
+				}
+			} else if body.Raw != "" {
+				t.Errorf("body = %q, want none", body.Raw)
+			}
+
+			form := body.URLEncoded
+			if body.Mode == models.BodyModeFormData {
+				form = body.FormData
+			}
+			if !reflect.DeepEqual(form, tt.form) {
+				t.Errorf("form = %+v, want %+v", form, tt.form)
+			}
+		})
+	}
+}
+
+func TestOpenAPIResolve(t *testing.T) {
+	doc := &openAPIDocument{root: map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Pet":     map[string]interface{}{"type": "object"},
+				"Alias":   map[string]interface{}{"$ref": "#/components/schemas/Pet"},
+				"Loop":    map[string]interface{}{"$ref": "#/components/schemas/Loop"},
+				"a/b~c":   map[string]interface{}{"type": "string"},
+				"Missing": map[string]interface{}{"$ref": "#/components/schemas/Nope"},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		ref  string
+		want map[string]interface{}
+	}{
+		{name: "local", ref: "#/components/schemas/Pet", want: map[string]interface{}{"type": "object"}},
+		{name: "chained", ref: "#/components/schemas/Alias", want: map[string]interface{}{"type": "object"}},
+		{name: "escaped token", ref: "#/components/schemas/a~1b~0c", want: map[string]interface{}{"type": "string"}},
+		{name: "cycle", ref: "#/components/schemas/Loop"},
+		{name: "dangling", ref: "#/components/schemas/Missing"},
+		{name: "other document", ref: "pets.yaml#/components/schemas/Pet"},
+		{name: "URL", ref: "https://example.com/schemas.json#/Pet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := doc.resolve(map[string]interface{}{"$ref": tt.ref})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
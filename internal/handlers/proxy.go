@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// proxySettings is the outbound proxy that applies to an execution
+type proxySettings struct {
+	url     string
+	noProxy string // Comma-separated hosts, domains and CIDRs reached directly, as in NO_PROXY
+}
+
+// proxyFunc returns the function selecting the proxy for each request,
+// including those that follow redirects
+func (p *proxySettings) proxyFunc() func(*http.Request) (*url.URL, error) {
+	config := &httpproxy.Config{
+		HTTPProxy:  p.url,
+		HTTPSProxy: p.url,
+		NoProxy:    p.noProxy,
+	}
+	proxy := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// proxyFor returns the proxy to reach u through, or nil for a direct connection
+func (p *proxySettings) proxyFor(u *url.URL) (*url.URL, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return p.proxyFunc()(&http.Request{URL: u})
+}
+
+// dialProxy opens a connection to address, a host and port, through a
+// proxy: a CONNECT tunnel for HTTP and HTTPS proxies, or SOCKS5. This is
+// for connections that http.Transport does not make, such as WebSockets.
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	switch strings.ToLower(proxyURL.Scheme) {
+	case "socks5", "socks5h":
+		socks, err := proxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	}
+
+	secure := strings.EqualFold(proxyURL.Scheme, "https")
+	port := proxyURL.Port()
+	if port == "" {
+		port = "80"
+		if secure {
+			port = "443"
+		}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(proxyURL.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if secure {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT failed: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, connect)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT failed: %w", err)
+	}
+	// The body of a CONNECT response is the tunnel, so it is not read
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT: %s", response.Status)
+	}
+	// The target speaks first only once the tunnel is used
+	if reader.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("proxy sent unexpected data after CONNECT")
+	}
+
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	return conn, nil
+}
+
+// loadEnvironmentProxy reads the proxy URL and no-proxy list stored on an
+// environment; empty values mean the environment does not set them
+func loadEnvironmentProxy(db *sql.DB, environmentID int) (string, string, error) {
+	var proxyURL, noProxy sql.NullString
+	err := db.QueryRow("SELECT proxy_url, no_proxy FROM environments WHERE id = $1", environmentID).Scan(&proxyURL, &noProxy)
+	if err != nil && err != sql.ErrNoRows {
+		return "", "", err
+	}
+	return proxyURL.String, noProxy.String, nil
+}
+
+// redactProxyURL hides the password of a proxy URL
+func redactProxyURL(proxyURL string) string {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Redacted()
+}
+
+// normalizeNoProxy trims the entries of a no-proxy list and drops empty ones
+func normalizeNoProxy(noProxy string) string {
+	var entries []string
+	for _, entry := range strings.Split(noProxy, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return strings.Join(entries, ",")
+}
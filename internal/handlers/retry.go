@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"postman-runner/internal/models"
+)
+
+// Retry policy defaults and limits
+const (
+	retryMaxAttempts         = 10
+	retryDefaultInitialDelay = 500 * time.Millisecond
+	retryDefaultMaxDelay     = 10 * time.Second
+	retryMaxDelay            = 60 * time.Second
+)
+
+var (
+	retryDefaultStatuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	retryDefaultErrors   = []string{models.RetryOnTimeout, models.RetryOnConnection}
+)
+
+// validateRetryPolicy checks the bounds of a retry policy
+func validateRetryPolicy(policy *models.RetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts < 0 || policy.MaxAttempts > retryMaxAttempts {
+		return fmt.Errorf("max_attempts must be between 0 and %d", retryMaxAttempts)
+	}
+	for _, status := range policy.RetryOnStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("retry_on_status contains invalid status code %d", status)
+		}
+	}
+	for _, class := range policy.RetryOnErrors {
+		switch class {
+		case models.RetryOnTimeout, models.RetryOnConnection, models.RetryOnDNS:
+		default:
+			return fmt.Errorf("retry_on_errors must only contain: timeout, connection, dns")
+		}
+	}
+	if policy.InitialDelayMs < 0 || policy.MaxDelayMs < 0 {
+		return fmt.Errorf("delays must not be negative")
+	}
+	if time.Duration(policy.MaxDelayMs)*time.Millisecond > retryMaxDelay {
+		return fmt.Errorf("max_delay_ms must be at most %d", retryMaxDelay.Milliseconds())
+	}
+	if policy.MaxDelayMs > 0 && policy.InitialDelayMs > policy.MaxDelayMs {
+		return fmt.Errorf("initial_delay_ms must not exceed max_delay_ms")
+	}
+	return nil
+}
+
+// decodeRetryPolicy converts a retry policy from a loosely decoded request body
+func decodeRetryPolicy(raw map[string]interface{}) (*models.RetryPolicy, error) {
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var policy models.RetryPolicy
+	if err := json.Unmarshal(encoded, &policy); err != nil {
+		return nil, fmt.Errorf("invalid retry_policy: %v", err)
+	}
+	if err := validateRetryPolicy(&policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// parseRetryPolicy deserializes a retry_policy JSONB column
+func parseRetryPolicy(data []byte) *models.RetryPolicy {
+	if len(data) == 0 {
+		return nil
+	}
+	var policy models.RetryPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil
+	}
+	return &policy
+}
+
+// encodeRetryPolicy serializes a policy for a retry_policy JSONB column.
+// Policies that never retry are stored as NULL.
+func encodeRetryPolicy(policy *models.RetryPolicy) (interface{}, error) {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// retrier applies a retry policy with its defaults filled in
+type retrier struct {
+	maxAttempts  int
+	statuses     []int
+	errors       []string
+	initialDelay time.Duration
+	maxDelay     time.Duration
+}
+
+// newRetrier returns nil when policy does not allow more than one attempt
+func newRetrier(policy *models.RetryPolicy) *retrier {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+	r := &retrier{
+		maxAttempts:  policy.MaxAttempts,
+		statuses:     policy.RetryOnStatus,
+		errors:       policy.RetryOnErrors,
+		initialDelay: time.Duration(policy.InitialDelayMs) * time.Millisecond,
+		maxDelay:     time.Duration(policy.MaxDelayMs) * time.Millisecond,
+	}
+	if r.statuses == nil {
+		r.statuses = retryDefaultStatuses
+	}
+	if r.errors == nil {
+		r.errors = retryDefaultErrors
+	}
+	if r.maxDelay == 0 {
+		r.maxDelay = max(retryDefaultMaxDelay, r.initialDelay)
+	}
+	if r.initialDelay == 0 {
+		r.initialDelay = min(retryDefaultInitialDelay, r.maxDelay)
+	}
+	return r
+}
+
+// nextDelay decides whether the outcome of an attempt is retried and how long
+// to wait first. A Retry-After header is honoured, unless it asks for a longer
+// wait than the policy allows, in which case the request is not retried.
+func (r *retrier) nextDelay(attempt int, response *models.ExecutionResponse, err error) (time.Duration, bool) {
+	if attempt >= r.maxAttempts {
+		return 0, false
+	}
+
+	if err != nil {
+		class := retryErrorClass(err)
+		if class == "" || !slices.Contains(r.errors, class) {
+			return 0, false
+		}
+		return r.backoff(attempt), true
+	}
+
+	if !slices.Contains(r.statuses, response.Status) {
+		return 0, false
+	}
+	if values := response.Headers["Retry-After"]; len(values) > 0 {
+		if delay, ok := parseRetryAfter(values[0], time.Now()); ok {
+			return delay, delay <= r.maxDelay
+		}
+	}
+	return r.backoff(attempt), true
+}
+
+// backoff doubles the delay with every attempt, up to the maximum, and
+// randomizes its upper half so that clients do not retry in lockstep
+func (r *retrier) backoff(attempt int) time.Duration {
+	delay := r.initialDelay
+	for i := 1; i < attempt && delay < r.maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, r.maxDelay)
+	if delay < 2 {
+		return delay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// parseRetryAfter reads a Retry-After value, given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryErrorClass returns the class of a request error that a retry policy
+// can select, or "" for errors that retrying would not fix
+func retryErrorClass(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return models.RetryOnDNS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return models.RetryOnTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return models.RetryOnConnection
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return models.RetryOnConnection
+	}
+	return ""
+}
+
+// retryError is returned when the last attempt of a retried request failed
+type retryError struct {
+	attempts []models.ExecutionAttempt
+	err      error
+}
+
+func (e *retryError) Error() string {
+	if len(e.attempts) == 1 {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("%v (after %d attempts)", e.err, len(e.attempts))
+}
+
+func (e *retryError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,609 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"postman-runner/internal/config"
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RunHandler struct {
+	db       *sql.DB
+	cfg      *config.Config
+	executor *ExecutionHandler
+}
+
+func NewRunHandler(db *sql.DB, cfg *config.Config) *RunHandler {
+	return &RunHandler{
+		db:       db,
+		cfg:      cfg,
+		executor: NewExecutionHandler(db, cfg),
+	}
+}
+
+// RunRequest represents the optional request body for collection and folder
+// runs. A retry policy given here applies to every request of the run. With
+// iteration data, the requests run once per row, with the row's values
+// layered over the environment variables.
+type RunRequest struct {
+	EnvironmentID *int                `json:"environment_id,omitempty"`
+	StopOnFailure bool                `json:"stop_on_failure,omitempty"`
+	RetryPolicy   *models.RetryPolicy `json:"retry_policy,omitempty"`
+	IterationData json.RawMessage     `json:"iteration_data,omitempty"` // JSON array of objects
+	MonitorID     *int                `json:"-"`                        // Set for runs started by a monitor
+
+	iterations []map[string]string // Parsed from IterationData or an uploaded data file
+}
+
+// RunCollection handles POST /collections/:id/run
+func (h *RunHandler) RunCollection(c *gin.Context) {
+	collectionIDStr := c.Param("id")
+	collectionID, err := strconv.Atoi(collectionIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Collection ID must be a valid integer",
+		})
+		return
+	}
+
+	// Verify collection exists
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE id = $1)", collectionID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check collection existence",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Collection not found",
+		})
+		return
+	}
+
+	h.startRun(c, collectionID, nil)
+}
+
+// RunFolder handles POST /items/:id/run
+func (h *RunHandler) RunFolder(c *gin.Context) {
+	itemIDStr := c.Param("id")
+	itemID, err := strconv.Atoi(itemIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	var collectionID int
+	var itemType string
+	err = h.db.QueryRow("SELECT collection_id, item_type FROM collection_items WHERE id = $1", itemID).Scan(&collectionID, &itemType)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Item not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch item",
+		})
+		return
+	}
+
+	if itemType != "folder" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_item_type",
+			Message: "Only items of type 'folder' can be run",
+		})
+		return
+	}
+
+	h.startRun(c, collectionID, &itemID)
+}
+
+// startRun parses the run options, executes the run and writes the report.
+// Options come as JSON, or as multipart form fields alongside a CSV or JSON
+// iteration data file in the "data" field. The run can be cancelled through
+// DELETE /executions/:id with the ID of any of its executions.
+func (h *RunHandler) startRun(c *gin.Context, collectionID int, folderID *int) {
+	var runReq RunRequest
+	var data []byte
+	var filename string
+	if c.ContentType() == "multipart/form-data" {
+		var err error
+		data, filename, err = h.bindRunForm(c, &runReq)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_request",
+				Message: fmt.Sprintf("Invalid request form: %v", err),
+			})
+			return
+		}
+	} else if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&runReq); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_request",
+				Message: fmt.Sprintf("Invalid request body: %v", err),
+			})
+			return
+		}
+		if len(runReq.IterationData) > 0 && string(runReq.IterationData) != "null" {
+			data, filename = runReq.IterationData, "iteration_data.json"
+		}
+	}
+	if data != nil {
+		var err error
+		runReq.iterations, err = parseIterationData(data, filename)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_iteration_data",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+	if err := validateRetryPolicy(runReq.RetryPolicy); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_retry_policy",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	run, execErr := h.executeRun(c.Request.Context(), collectionID, folderID, runReq)
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// bindRunForm reads run options from a multipart form: environment_id,
+// stop_on_failure and retry_policy as JSON. The content and name of the
+// iteration data file in the "data" field are returned, if one was sent.
+func (h *RunHandler) bindRunForm(c *gin.Context, runReq *RunRequest) ([]byte, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.MaxRequestSize)
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if value := formValue(form, "environment_id"); value != "" {
+		envID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("environment_id must be a valid integer")
+		}
+		runReq.EnvironmentID = &envID
+	}
+	if value := formValue(form, "stop_on_failure"); value != "" {
+		runReq.StopOnFailure, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("stop_on_failure must be true or false")
+		}
+	}
+	if value := formValue(form, "retry_policy"); value != "" {
+		if err := json.Unmarshal([]byte(value), &runReq.RetryPolicy); err != nil {
+			return nil, "", fmt.Errorf("retry_policy must be a JSON object: %v", err)
+		}
+	}
+
+	files := form.File["data"]
+	if len(files) == 0 {
+		return nil, "", nil
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open data file: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read data file: %v", err)
+	}
+	return data, files[0].Filename, nil
+}
+
+// formValue returns the first value of a multipart form field
+func formValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// executeRun executes every request item of a collection (or of a folder
+// subtree) in tree order and persists a run report. Variables extracted by
+// one request are visible to the requests that follow it. With iteration
+// data the requests run once per row, each row's values taking precedence
+// over environment and extracted variables. Once ctx ends, or the run is
+// cancelled through one of its executions, the request in flight is
+// abandoned and the run is reported as cancelled.
+func (h *RunHandler) executeRun(ctx context.Context, collectionID int, folderID *int, runReq RunRequest) (*models.Run, *executionError) {
+	// Load environment variables for {{variable}} substitution
+	variables := make(map[string]string)
+	if runReq.EnvironmentID != nil {
+		var err error
+		variables, err = loadEnvironmentVariables(h.db, *runReq.EnvironmentID)
+		if err == sql.ErrNoRows {
+			return nil, &executionError{Status: http.StatusNotFound, Code: "not_found", Message: "Environment not found"}
+		}
+		if err != nil {
+			return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to fetch environment"}
+		}
+	}
+
+	// Collect request items in tree order
+	flatItems, err := fetchCollectionItems(h.db, collectionID)
+	if err != nil {
+		return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to fetch collection items"}
+	}
+	requests := filterRunnableItems(flatItems, folderID)
+
+	// Without iteration data the requests run once
+	dataDriven := len(runReq.iterations) > 0
+	iterations := runReq.iterations
+	var iterationDataJSON interface{}
+	if dataDriven {
+		encoded, err := json.Marshal(iterations)
+		if err != nil {
+			return nil, &executionError{Status: http.StatusInternalServerError, Code: "internal_error", Message: "Failed to encode iteration data"}
+		}
+		iterationDataJSON = string(encoded)
+	} else {
+		iterations = []map[string]string{nil}
+	}
+
+	// Create run record
+	runStart := time.Now()
+	run := models.Run{
+		CollectionID:  collectionID,
+		FolderID:      folderID,
+		EnvironmentID: runReq.EnvironmentID,
+		MonitorID:     runReq.MonitorID,
+		Status:        "running",
+		TotalRequests: len(requests) * len(iterations),
+		Results:       []models.RunResult{},
+	}
+	err = h.db.QueryRow(`
+		INSERT INTO runs (collection_id, folder_id, environment_id, monitor_id, status, total_requests, iteration_data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, started_at
+	`, collectionID, folderID, runReq.EnvironmentID, runReq.MonitorID, run.Status, run.TotalRequests, iterationDataJSON).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to create run"}
+	}
+
+	ctx, cancel, release := cancellable(ctx)
+	defer release()
+	defer inflightRuns.add(run.ID, cancel)()
+
+	execReq := ExecutionRequest{EnvironmentID: runReq.EnvironmentID, RetryPolicy: runReq.RetryPolicy, runID: run.ID}
+	sequence := 0
+	cancelled := false
+iterationLoop:
+	for n, row := range iterations {
+		for i := range requests {
+			if ctx.Err() != nil {
+				cancelled = true
+				break iterationLoop
+			}
+			item := &requests[i]
+			sequence++
+			result := models.RunResult{
+				ItemID:   &item.ID,
+				Sequence: sequence,
+				ItemName: item.Name,
+				Method:   item.Method.String,
+				URL:      item.URL.String,
+			}
+			if dataDriven {
+				result.Iteration = n + 1
+			}
+
+			startTime := time.Now()
+			response, execErr := h.executor.executeItem(ctx, item, execReq, layerVariables(variables, row))
+			if execErr != nil {
+				result.DurationMs = time.Since(startTime).Milliseconds()
+				result.Error = execErr.Message
+				result.Cancelled = execErr.Code == "cancelled"
+				if execErr.ExecutionID != 0 {
+					result.ExecutionID = &execErr.ExecutionID
+				}
+			} else {
+				if response.ExecutionID != 0 {
+					result.ExecutionID = &response.ExecutionID
+				}
+				result.DurationMs = response.DurationMs
+				result.StatusCode = &response.Status
+				result.Assertions = response.Assertions
+
+				result.Passed, result.Error = responsePassed(response)
+
+				// Make extracted variables available to subsequent requests
+				for key, value := range response.ExtractedVariables {
+					variables[key] = value
+				}
+			}
+
+			var assertionResultsJSON interface{}
+			if len(result.Assertions) > 0 {
+				encoded, err := json.Marshal(result.Assertions)
+				if err == nil {
+					assertionResultsJSON = string(encoded)
+				}
+			}
+			var iteration interface{}
+			if result.Iteration > 0 {
+				iteration = result.Iteration
+			}
+
+			err = h.db.QueryRow(`
+				INSERT INTO run_results (run_id, item_id, sequence, iteration, item_name, method, url, status_code, duration_ms, passed, error, cancelled, execution_id, assertion_results)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+				RETURNING id
+			`, run.ID, result.ItemID, result.Sequence, iteration, result.ItemName, nullString(result.Method), result.URL,
+				result.StatusCode, result.DurationMs, result.Passed, nullString(result.Error), result.Cancelled, result.ExecutionID,
+				assertionResultsJSON).Scan(&result.ID)
+			if err != nil {
+				h.failRun(&run, runStart)
+				return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to save run result"}
+			}
+
+			run.Results = append(run.Results, result)
+			// A cancelled request neither passed nor failed
+			if result.Cancelled {
+				cancelled = true
+				break iterationLoop
+			}
+			if result.Passed {
+				run.PassedRequests++
+			} else {
+				run.FailedRequests++
+				if runReq.StopOnFailure {
+					break iterationLoop
+				}
+			}
+		}
+	}
+
+	// Finalize run record
+	run.Status = "passed"
+	if cancelled {
+		run.Status = "cancelled"
+	} else if run.FailedRequests > 0 {
+		run.Status = "failed"
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(runStart).Milliseconds()
+
+	_, err = h.db.Exec(`
+		UPDATE runs
+		SET status = $1, passed_requests = $2, failed_requests = $3, duration_ms = $4, finished_at = NOW()
+		WHERE id = $5
+	`, run.Status, run.PassedRequests, run.FailedRequests, run.DurationMs, run.ID)
+	if err != nil {
+		h.failRun(&run, runStart)
+		return nil, &executionError{Status: http.StatusInternalServerError, Code: "database_error", Message: "Failed to update run"}
+	}
+
+	if dataDriven {
+		run.Iterations = groupRunIterations(runReq.iterations, run.Results)
+		run.Results = []models.RunResult{}
+	}
+
+	return &run, nil
+}
+
+// failRun marks a run that could not be recorded to the end as failed, so
+// that it is not left running
+func (h *RunHandler) failRun(run *models.Run, runStart time.Time) {
+	_, err := h.db.Exec(`
+		UPDATE runs
+		SET status = 'failed', passed_requests = $1, failed_requests = $2, duration_ms = $3, finished_at = NOW()
+		WHERE id = $4
+	`, run.PassedRequests, run.FailedRequests, time.Since(runStart).Milliseconds(), run.ID)
+	if err != nil {
+		log.Printf("Failed to mark run %d as failed: %v", run.ID, err)
+	}
+}
+
+// responsePassed decides the outcome of a response. Assertions decide it
+// when present, otherwise any 4xx/5xx status fails the request.
+func responsePassed(response *models.ExecutionResponse) (bool, string) {
+	if len(response.Assertions) > 0 {
+		return assertionsPassed(response.Assertions)
+	}
+	if response.Status >= 400 {
+		return false, fmt.Sprintf("Request returned HTTP %d", response.Status)
+	}
+	return true, ""
+}
+
+// filterRunnableItems returns the request items of a flat, tree-ordered item
+// list. When folderID is set only requests inside that folder are kept.
+func filterRunnableItems(items []models.CollectionItem, folderID *int) []models.CollectionItem {
+	included := make(map[int]bool)
+	if folderID != nil {
+		included[*folderID] = true
+	}
+
+	var requests []models.CollectionItem
+	for _, item := range items {
+		if folderID != nil {
+			// Parents always precede their children in tree order
+			if !item.ParentID.Valid || !included[int(item.ParentID.Int64)] {
+				continue
+			}
+			included[item.ID] = true
+		}
+		if item.ItemType == "request" {
+			requests = append(requests, item)
+		}
+	}
+	return requests
+}
+
+// GetRun handles GET /runs/:id
+func (h *RunHandler) GetRun(c *gin.Context) {
+	runIDStr := c.Param("id")
+	runID, err := strconv.Atoi(runIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Run ID must be a valid integer",
+		})
+		return
+	}
+
+	var run models.Run
+	var folderID, environmentID, monitorID sql.NullInt64
+	var finishedAt sql.NullTime
+	var iterationDataJSON []byte
+	err = h.db.QueryRow(`
+		SELECT id, collection_id, folder_id, environment_id, monitor_id, status, total_requests,
+			passed_requests, failed_requests, duration_ms, started_at, finished_at, iteration_data
+		FROM runs
+		WHERE id = $1
+	`, runID).Scan(
+		&run.ID,
+		&run.CollectionID,
+		&folderID,
+		&environmentID,
+		&monitorID,
+		&run.Status,
+		&run.TotalRequests,
+		&run.PassedRequests,
+		&run.FailedRequests,
+		&run.DurationMs,
+		&run.StartedAt,
+		&finishedAt,
+		&iterationDataJSON,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Run not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch run",
+		})
+		return
+	}
+
+	run.FolderID = nullIntPtr(folderID)
+	run.EnvironmentID = nullIntPtr(environmentID)
+	run.MonitorID = nullIntPtr(monitorID)
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, item_id, sequence, iteration, item_name, method, url, status_code, duration_ms, passed, error, cancelled, execution_id, assertion_results
+		FROM run_results
+		WHERE run_id = $1
+		ORDER BY sequence
+	`, runID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch run results",
+		})
+		return
+	}
+	defer rows.Close()
+
+	run.Results = []models.RunResult{}
+	for rows.Next() {
+		var result models.RunResult
+		var itemID, iteration, statusCode, executionID sql.NullInt64
+		var method, urlStr, errMsg sql.NullString
+		var assertionResultsJSON []byte
+		if err := rows.Scan(
+			&result.ID,
+			&itemID,
+			&result.Sequence,
+			&iteration,
+			&result.ItemName,
+			&method,
+			&urlStr,
+			&statusCode,
+			&result.DurationMs,
+			&result.Passed,
+			&errMsg,
+			&result.Cancelled,
+			&executionID,
+			&assertionResultsJSON,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to scan run result",
+			})
+			return
+		}
+
+		result.ItemID = nullIntPtr(itemID)
+		result.Iteration = int(iteration.Int64)
+		result.StatusCode = nullIntPtr(statusCode)
+		result.Method = method.String
+		result.URL = urlStr.String
+		result.Error = errMsg.String
+		result.ExecutionID = nullIntPtr(executionID)
+		if len(assertionResultsJSON) > 0 {
+			if err := json.Unmarshal(assertionResultsJSON, &result.Assertions); err != nil {
+				result.Assertions = nil
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	if len(iterationDataJSON) > 0 {
+		var iterationData []map[string]string
+		if err := json.Unmarshal(iterationDataJSON, &iterationData); err == nil {
+			run.Iterations = groupRunIterations(iterationData, run.Results)
+			run.Results = []models.RunResult{}
+		}
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func nullString(val string) interface{} {
+	if val == "" {
+		return nil
+	}
+	return val
+}
+
+func nullIntPtr(val sql.NullInt64) *int {
+	if !val.Valid {
+		return nil
+	}
+	i := int(val.Int64)
+	return &i
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"postman-runner/internal/config"
+	"postman-runner/internal/models"
+)
+
+// MonitorScheduler runs due monitors through the collection runner. Every
+// replica sharing the database may run one: a due monitor is claimed by
+// locking its row and moving it to its next scheduled time, so each
+// scheduled run happens once. Runs missed while no scheduler was running
+// are skipped rather than caught up.
+type MonitorScheduler struct {
+	db     *sql.DB
+	cfg    *config.Config
+	runner *RunHandler
+	slots  chan struct{} // Holds a token per monitor run in progress
+}
+
+func NewMonitorScheduler(db *sql.DB, cfg *config.Config) *MonitorScheduler {
+	return &MonitorScheduler{
+		db:     db,
+		cfg:    cfg,
+		runner: NewRunHandler(db, cfg),
+		slots:  make(chan struct{}, cfg.MonitorMaxConcurrentRuns),
+	}
+}
+
+// Run polls for due monitors until ctx is done
+func (s *MonitorScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.MonitorPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.startDueMonitors(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startDueMonitors claims and starts due monitors while run slots are free.
+// Monitors left over are claimed by the next poll, here or on another replica.
+func (s *MonitorScheduler) startDueMonitors(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		monitor, err := s.claimDueMonitor()
+		if err != nil {
+			log.Printf("Failed to claim due monitor: %v", err)
+		}
+		if monitor == nil {
+			<-s.slots
+			return
+		}
+
+		go func() {
+			defer func() { <-s.slots }()
+			s.runMonitor(ctx, monitor)
+		}()
+	}
+}
+
+// claimDueMonitor picks the most overdue monitor, skipping rows other
+// replicas hold locked, and moves it to its next scheduled time. Returns
+// nil when no monitor is due.
+func (s *MonitorScheduler) claimDueMonitor() (*models.Monitor, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	monitor, err := scanMonitor(tx.QueryRow(`
+		SELECT `+monitorColumns+`
+		FROM monitors
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, now))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Schedules are validated when saved, but the timezone database may differ between replicas
+	var next *time.Time
+	lastError := ""
+	schedule, err := parseMonitorSchedule(monitor.CronExpression, monitor.Timezone)
+	if err != nil {
+		lastError = err.Error()
+	} else {
+		next = nextRunAt(schedule, true)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE monitors SET next_run_at = $1, last_run_at = $2, last_error = $3 WHERE id = $4
+	`, next, now, nullString(lastError), monitor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if lastError != "" {
+		log.Printf("Monitor %d is no longer scheduled: %s", monitor.ID, lastError)
+		return nil, nil
+	}
+	return monitor, nil
+}
+
+// runMonitor runs a claimed monitor, within ctx, and records why it failed
+// to start, if it did
+func (s *MonitorScheduler) runMonitor(ctx context.Context, monitor *models.Monitor) {
+	runReq := RunRequest{
+		EnvironmentID: monitor.EnvironmentID,
+		StopOnFailure: monitor.StopOnFailure,
+		MonitorID:     &monitor.ID,
+	}
+	run, execErr := s.runner.executeRun(ctx, monitor.CollectionID, monitor.FolderID, runReq)
+
+	lastError := ""
+	if execErr != nil {
+		log.Printf("Monitor %d failed to run: %s", monitor.ID, execErr.Message)
+		lastError = execErr.Message
+	} else {
+		log.Printf("Monitor %d run %d %s (%d/%d requests passed)", monitor.ID, run.ID, run.Status, run.PassedRequests, run.TotalRequests)
+	}
+
+	if _, err := s.db.Exec(`UPDATE monitors SET last_error = $1 WHERE id = $2`, nullString(lastError), monitor.ID); err != nil {
+		log.Printf("Failed to update monitor %d: %v", monitor.ID, err)
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signAWSV4 signs req with AWS Signature Version 4
+func signAWSV4(req *authRequest, params map[string]string) error {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	region := params["region"]
+	if region == "" {
+		region = "us-east-1"
+	}
+	service := params["service"]
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex([]byte(req.payload()))
+
+	setHeader(req.Headers, "X-Amz-Date", amzDate)
+	setHeader(req.Headers, "X-Amz-Content-Sha256", payloadHash)
+	if token := params["sessionToken"]; token != "" {
+		setHeader(req.Headers, "X-Amz-Security-Token", token)
+	}
+
+	// Sign the host, content type and every x-amz-* header
+	signed := map[string]string{"host": u.Host}
+	for key, value := range req.Headers {
+		lower := strings.ToLower(key)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			signed[lower] = strings.Join(strings.Fields(value), " ")
+		}
+	}
+	signedNames := sortedKeys(signed)
+	var canonicalHeaders strings.Builder
+	for _, name := range signedNames {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(signedNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(u, service),
+		awsCanonicalQuery(u),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSum(sha256.New, []byte("AWS4"+params["secretKey"]), []byte(date))
+	key = hmacSum(sha256.New, key, []byte(region))
+	key = hmacSum(sha256.New, key, []byte(service))
+	key = hmacSum(sha256.New, key, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, []byte(stringToSign)))
+
+	setHeader(req.Headers, "Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		params["accessKey"], scope, signedHeaders, signature))
+	return nil
+}
+
+// awsCanonicalURI encodes each path segment, twice for every service but S3
+func awsCanonicalURI(u *url.URL, service string) string {
+	if u.Path == "" {
+		return "/"
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segment = awsEscape(segment)
+		if service != "s3" {
+			segment = awsEscape(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything but RFC 3986 unreserved characters
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// signHMAC adds an HMAC signature header. By default the signature covers the
+// body only, as with most webhook schemes; the canonical payload also covers
+// the method, the request URI and a timestamp sent alongside it:
+//
+//	METHOD\nREQUEST_URI\nTIMESTAMP\nBODY
+func signHMAC(req *authRequest, params map[string]string) error {
+	algorithm := strings.ToLower(params["algorithm"])
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := hmacAlgorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported hmac algorithm '%s'", params["algorithm"])
+	}
+
+	message := req.payload()
+	if params["payload"] == "canonical" {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		timestampHeader := params["timestampHeader"]
+		if timestampHeader == "" {
+			timestampHeader = "X-Timestamp"
+		}
+		setHeader(req.Headers, timestampHeader, timestamp)
+		message = strings.Join([]string{req.Method, u.RequestURI(), timestamp, message}, "\n")
+	}
+
+	sum := hmacSum(newHash, []byte(params["secret"]), []byte(message))
+	signature := hex.EncodeToString(sum)
+	if params["encoding"] == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+
+	header := params["header"]
+	if header == "" {
+		header = "X-Signature"
+	}
+	setHeader(req.Headers, header, params["prefix"]+signature)
+	return nil
+}
+
+// digestAuthorization answers a Digest challenge from a WWW-Authenticate
+// header (RFC 7616). Returns false if none of the challenges is Digest.
+func digestAuthorization(challenges []string, req *authRequest, params map[string]string) (string, bool, error) {
+	var challenge map[string]string
+	for _, header := range challenges {
+		if len(header) > 7 && strings.EqualFold(header[:7], "Digest ") {
+			challenge = parseAuthParams(header[7:])
+			break
+		}
+	}
+	if challenge == nil {
+		return "", false, nil
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	algorithm := challenge["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", false, fmt.Errorf("unsupported digest algorithm '%s'", algorithm)
+	}
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", false, err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	const nc = "00000001"
+
+	realm, nonce, uri := challenge["realm"], challenge["nonce"], u.RequestURI()
+	ha1 := h(params["username"] + ":" + realm + ":" + params["password"])
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+
+	qop := ""
+	for _, offered := range strings.Split(challenge["qop"], ",") {
+		if strings.TrimSpace(offered) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop != "" {
+		response = h(strings.Join([]string{ha1, nonce, nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, escapeQuotes(params["username"])),
+		fmt.Sprintf(`realm="%s"`, escapeQuotes(realm)),
+		fmt.Sprintf(`nonce="%s"`, escapeQuotes(nonce)),
+		fmt.Sprintf(`uri="%s"`, escapeQuotes(uri)),
+		"algorithm=" + algorithm,
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, escapeQuotes(opaque)))
+	}
+	return "Digest " + strings.Join(parts, ", "), true, nil
+}
+
+// parseAuthParams parses the comma-separated key=value pairs of an
+// authentication challenge, where values may be quoted strings
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+}
+
+func hmacSum(newHash func() hash.Hash, key, message []byte) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
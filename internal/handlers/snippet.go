@@ -0,0 +1,642 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// snippetLanguages lists the languages snippets can be rendered in
+var snippetLanguages = []string{"curl", "go", "python-requests", "js-fetch", "httpie", "k6"}
+
+var snippetRenderers = map[string]func(*snippetRequest) string{
+	"curl":            renderCurlSnippet,
+	"go":              renderGoSnippet,
+	"python-requests": renderPythonSnippet,
+	"js-fetch":        renderFetchSnippet,
+	"httpie":          renderHTTPieSnippet,
+	"k6":              renderK6Snippet,
+}
+
+// GetSnippet handles GET /items/:id/snippet?lang=... The stored request is
+// rendered as code that sends it, with the variables of the environment
+// given by environment_id resolved. Placeholders without a value are left
+// in place and listed. Auth settings are not included.
+func (h *ExecutionHandler) GetSnippet(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	lang := c.Query("lang")
+	render, ok := snippetRenderers[lang]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_lang",
+			Message: fmt.Sprintf("lang must be one of: %s", strings.Join(snippetLanguages, ", ")),
+		})
+		return
+	}
+
+	var execReq ExecutionRequest
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		envID, err := strconv.Atoi(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_id",
+				Message: "environment_id must be a valid integer",
+			})
+			return
+		}
+		execReq.EnvironmentID = &envID
+	}
+
+	item, execErr := h.fetchExecutableItem(itemID, "request")
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	variables, ok := h.loadExecutionVariables(c, execReq)
+	if !ok {
+		return
+	}
+
+	resolver := newVariableResolver(variables)
+	req, execErr := buildSnippetRequest(item, resolver)
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Snippet{
+		ItemID:              itemID,
+		Language:            lang,
+		Snippet:             render(req),
+		UnresolvedVariables: resolver.Unresolved(),
+	})
+}
+
+// Kinds of snippet bodies
+const (
+	snippetBodyNone = iota
+	snippetBodyText
+	snippetBodyForm
+	snippetBodyFile
+)
+
+// snippetRequest is a request item as snippets send it
+type snippetRequest struct {
+	method   string
+	url      string
+	headers  []snippetHeader // In stored order, followed by those the body implies
+	bodyKind int
+	body     string             // For text bodies
+	form     []snippetFormField // For form-data bodies
+	file     string             // Path of the file sent as the body in file mode
+}
+
+type snippetHeader struct {
+	name  string
+	value string
+}
+
+// snippetFormField is a form-data part, a text value or a file read from path
+type snippetFormField struct {
+	name        string
+	value       string
+	isFile      bool
+	path        string
+	filename    string
+	contentType string
+}
+
+// buildSnippetRequest resolves an item into the request a snippet sends. The
+// body is built as when executing, except that form-data and file bodies are
+// left for the snippet to assemble from the files they name.
+func buildSnippetRequest(item *models.CollectionItem, resolver *variableResolver) (*snippetRequest, *executionError) {
+	if !item.Method.Valid {
+		return nil, &executionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Request is missing method"}
+	}
+	if !item.URL.Valid || item.URL.String == "" {
+		return nil, &executionError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Request URL is missing"}
+	}
+	req := &snippetRequest{method: item.Method.String, url: resolver.Resolve(item.URL.String)}
+
+	// As when executing, a repeated header replaces the earlier one
+	headers := make(map[string]string)
+	var order []string
+	if item.Headers.Valid && item.Headers.String != "" {
+		var postmanHeaders []models.PostmanHeader
+		if err := json.Unmarshal([]byte(item.Headers.String), &postmanHeaders); err == nil {
+			for _, header := range postmanHeaders {
+				key := resolver.Resolve(header.Key)
+				if _, seen := headers[key]; !seen {
+					order = append(order, key)
+				}
+				headers[key] = resolver.Resolve(header.Value)
+			}
+		}
+	}
+
+	data := item.BodyData
+	if data == nil {
+		data = &models.BodyData{}
+	}
+	switch {
+	case item.BodyMode == models.BodyModeGraphQL && req.method == http.MethodGet:
+		var err error
+		req.url, err = graphQLQueryURL(req.url, item.BodyData, resolver)
+		if err != nil {
+			return nil, invalidSnippetBody(err)
+		}
+
+	case !validator.MethodAllowsBody(req.method):
+		// Bodies are not sent with methods that do not take one
+
+	case item.BodyMode == models.BodyModeFormData:
+		req.bodyKind = snippetBodyForm
+		for _, param := range data.FormData {
+			if param.Disabled {
+				continue
+			}
+			field := snippetFormField{name: resolver.Resolve(param.Key), contentType: param.ContentType}
+			if param.Type == "file" {
+				field.isFile = true
+				field.path = snippetFilePath(param.Src)
+				field.filename = fileName(param.Src)
+			} else {
+				field.value = resolver.Resolve(param.Value)
+			}
+			req.form = append(req.form, field)
+		}
+		// Clients set the multipart content type themselves, with its boundary
+		if key, ok := headerKey(headers, "Content-Type"); ok {
+			delete(headers, key)
+		}
+
+	case item.BodyMode == models.BodyModeFile:
+		if data.File != nil {
+			req.bodyKind = snippetBodyFile
+			req.file = snippetFilePath(data.File.Src)
+			setDefaultHeader(headers, "Content-Type", "application/octet-stream")
+		}
+
+	default:
+		body, err := buildRequestBody(item.BodyMode, item.Body.String, item.BodyData, resolver, headers)
+		if err != nil {
+			return nil, invalidSnippetBody(err)
+		}
+		if body != "" {
+			req.bodyKind = snippetBodyText
+			req.body = body
+		}
+	}
+
+	for _, key := range order {
+		if value, ok := headers[key]; ok {
+			req.headers = append(req.headers, snippetHeader{name: key, value: value})
+			delete(headers, key)
+		}
+	}
+	implied := make([]string, 0, len(headers))
+	for key := range headers {
+		implied = append(implied, key)
+	}
+	sort.Strings(implied)
+	for _, key := range implied {
+		req.headers = append(req.headers, snippetHeader{name: key, value: headers[key]})
+	}
+
+	return req, nil
+}
+
+func invalidSnippetBody(err error) *executionError {
+	return &executionError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_body",
+		Message: fmt.Sprintf("Failed to build request body: %v", err),
+	}
+}
+
+// snippetFilePath returns the local path a Postman src names
+func snippetFilePath(src interface{}) string {
+	switch v := src.(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			if p, ok := v[0].(string); ok && p != "" {
+				return p
+			}
+		}
+	}
+	return "file"
+}
+
+// shellSafe matches words that need no quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+:,./-]+$`)
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsonQuote writes s as a JSON string, which is also a valid JavaScript and
+// Python string literal
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// shellCommand joins the lines of a command with continuations
+func shellCommand(lines []string) string {
+	return strings.Join(lines, " \\\n  ") + "\n"
+}
+
+func renderCurlSnippet(req *snippetRequest) string {
+	first := []string{"curl", "--location"}
+	switch {
+	case req.method == http.MethodHead:
+		first = append(first, "--head")
+	case req.method != http.MethodGet || req.bodyKind != snippetBodyNone:
+		first = append(first, "--request", shellQuote(req.method))
+	}
+	lines := []string{strings.Join(append(first, shellQuote(req.url)), " ")}
+
+	for _, header := range req.headers {
+		// curl drops headers given without a value unless they end with ;
+		if header.value == "" {
+			lines = append(lines, "--header "+shellQuote(header.name+";"))
+		} else {
+			lines = append(lines, "--header "+shellQuote(header.name+": "+header.value))
+		}
+	}
+
+	switch req.bodyKind {
+	case snippetBodyText:
+		lines = append(lines, "--data-raw "+shellQuote(req.body))
+	case snippetBodyFile:
+		lines = append(lines, "--data-binary "+shellQuote("@"+req.file))
+	case snippetBodyForm:
+		for _, field := range req.form {
+			if !field.isFile {
+				// --form-string does not treat a leading @ or < in the value as a file
+				lines = append(lines, "--form-string "+shellQuote(field.name+"="+field.value))
+				continue
+			}
+			value := fmt.Sprintf(`%s=@"%s"`, field.name, escapeQuotes(field.path))
+			if field.contentType != "" {
+				value += ";type=" + field.contentType
+			}
+			lines = append(lines, "--form "+shellQuote(value))
+		}
+	}
+
+	return shellCommand(lines)
+}
+
+// httpieEscape escapes the characters HTTPie reads as request item separators
+func httpieEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`:=@;\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// httpieValue escapes a leading character of a value that HTTPie would read
+// as part of a longer separator, such as := or =@
+func httpieValue(s string) string {
+	if strings.HasPrefix(s, "=") || strings.HasPrefix(s, "@") {
+		return `\` + s
+	}
+	return s
+}
+
+func renderHTTPieSnippet(req *snippetRequest) string {
+	first := []string{"http", "--follow"}
+	if req.bodyKind == snippetBodyForm {
+		first = append(first, "--multipart")
+	}
+	lines := []string{strings.Join(append(first, shellQuote(req.method), shellQuote(req.url)), " ")}
+
+	for _, header := range req.headers {
+		// A header item without a value removes the header unless it ends with ;
+		if header.value == "" {
+			lines = append(lines, shellQuote(httpieEscape(header.name)+";"))
+		} else {
+			lines = append(lines, shellQuote(httpieEscape(header.name)+":"+httpieValue(header.value)))
+		}
+	}
+
+	switch req.bodyKind {
+	case snippetBodyText:
+		lines = append(lines, "--raw "+shellQuote(req.body))
+	case snippetBodyFile:
+		lines = append(lines, "< "+shellQuote(req.file))
+	case snippetBodyForm:
+		for _, field := range req.form {
+			if !field.isFile {
+				lines = append(lines, shellQuote(httpieEscape(field.name)+"="+httpieValue(field.value)))
+				continue
+			}
+			value := httpieEscape(field.name) + "@" + field.path
+			if field.contentType != "" {
+				value += ";type=" + field.contentType
+			}
+			lines = append(lines, shellQuote(value))
+		}
+	}
+
+	return shellCommand(lines)
+}
+
+func renderPythonSnippet(req *snippetRequest) string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", jsonQuote(req.url))
+
+	args := []string{jsonQuote(req.method), "url"}
+	if len(req.headers) > 0 {
+		b.WriteString("\nheaders = {\n")
+		for _, header := range req.headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(header.name), jsonQuote(header.value))
+		}
+		b.WriteString("}\n")
+		args = append(args, "headers=headers")
+	}
+
+	switch req.bodyKind {
+	case snippetBodyText:
+		// Encoded up front, as requests sends str bodies as Latin-1
+		fmt.Fprintf(&b, "\ndata = %s.encode(\"utf-8\")\n", jsonQuote(req.body))
+		args = append(args, "data=data")
+	case snippetBodyFile:
+		fmt.Fprintf(&b, "\ndata = open(%s, \"rb\")\n", jsonQuote(req.file))
+		args = append(args, "data=data")
+	case snippetBodyForm:
+		b.WriteString("\nfiles = [\n")
+		for _, field := range req.form {
+			var part []string
+			if field.isFile {
+				part = []string{jsonQuote(field.filename), fmt.Sprintf("open(%s, \"rb\")", jsonQuote(field.path))}
+			} else {
+				part = []string{"None", jsonQuote(field.value)}
+			}
+			if field.contentType != "" {
+				part = append(part, jsonQuote(field.contentType))
+			}
+			fmt.Fprintf(&b, "    (%s, (%s)),\n", jsonQuote(field.name), strings.Join(part, ", "))
+		}
+		b.WriteString("]\n")
+		args = append(args, "files=files")
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s)\n\n", strings.Join(args, ", "))
+	b.WriteString("print(response.status_code)\n")
+	b.WriteString("print(response.text)\n")
+	return b.String()
+}
+
+func renderFetchSnippet(req *snippetRequest) string {
+	var b strings.Builder
+	readsFiles := req.bodyKind == snippetBodyFile
+	for _, field := range req.form {
+		readsFiles = readsFiles || field.isFile
+	}
+	if readsFiles {
+		b.WriteString("import { openAsBlob } from \"node:fs\";\n\n")
+	}
+
+	body := ""
+	switch req.bodyKind {
+	case snippetBodyText:
+		body = jsonQuote(req.body)
+	case snippetBodyFile:
+		body = fmt.Sprintf("await openAsBlob(%s)", jsonQuote(req.file))
+	case snippetBodyForm:
+		b.WriteString("const form = new FormData();\n")
+		for _, field := range req.form {
+			if !field.isFile {
+				fmt.Fprintf(&b, "form.append(%s, %s);\n", jsonQuote(field.name), jsonQuote(field.value))
+				continue
+			}
+			blob := fmt.Sprintf("await openAsBlob(%s)", jsonQuote(field.path))
+			if field.contentType != "" {
+				blob = fmt.Sprintf("await openAsBlob(%s, { type: %s })", jsonQuote(field.path), jsonQuote(field.contentType))
+			}
+			fmt.Fprintf(&b, "form.append(%s, %s, %s);\n", jsonQuote(field.name), blob, jsonQuote(field.filename))
+		}
+		b.WriteString("\n")
+		body = "form"
+	}
+
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsonQuote(req.url))
+	fmt.Fprintf(&b, "  method: %s,\n", jsonQuote(req.method))
+	if len(req.headers) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, header := range req.headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(header.name), jsonQuote(header.value))
+		}
+		b.WriteString("  },\n")
+	}
+	if body != "" {
+		fmt.Fprintf(&b, "  body: %s,\n", body)
+	}
+	b.WriteString("});\n\n")
+	b.WriteString("console.log(response.status);\n")
+	b.WriteString("console.log(await response.text());\n")
+	return b.String()
+}
+
+func renderK6Snippet(req *snippetRequest) string {
+	var b strings.Builder
+	b.WriteString("import http from \"k6/http\";\n")
+	if req.bodyKind == snippetBodyForm {
+		b.WriteString("import { FormData } from \"https://jslib.k6.io/formdata/0.0.2/index.js\";\n")
+	}
+
+	// Files can only be read in the init context
+	files := 0
+	switch req.bodyKind {
+	case snippetBodyFile:
+		fmt.Fprintf(&b, "\nconst body = open(%s, \"b\");\n", jsonQuote(req.file))
+	case snippetBodyForm:
+		for _, field := range req.form {
+			if field.isFile {
+				if files == 0 {
+					b.WriteString("\n")
+				}
+				fmt.Fprintf(&b, "const file%d = open(%s, \"b\");\n", files, jsonQuote(field.path))
+				files++
+			}
+		}
+	}
+
+	b.WriteString("\nexport default function () {\n")
+	body := "null"
+	switch req.bodyKind {
+	case snippetBodyText:
+		body = jsonQuote(req.body)
+	case snippetBodyFile:
+		body = "body"
+	case snippetBodyForm:
+		b.WriteString("  const form = new FormData();\n")
+		file := 0
+		for _, field := range req.form {
+			if !field.isFile {
+				fmt.Fprintf(&b, "  form.append(%s, %s);\n", jsonQuote(field.name), jsonQuote(field.value))
+				continue
+			}
+			contentType := field.contentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			fmt.Fprintf(&b, "  form.append(%s, http.file(file%d, %s, %s));\n",
+				jsonQuote(field.name), file, jsonQuote(field.filename), jsonQuote(contentType))
+			file++
+		}
+		b.WriteString("\n")
+		body = "form.body()"
+	}
+
+	fmt.Fprintf(&b, "  const res = http.request(%s, %s, %s, {\n", jsonQuote(req.method), jsonQuote(req.url), body)
+	b.WriteString("    headers: {\n")
+	for _, header := range req.headers {
+		fmt.Fprintf(&b, "      %s: %s,\n", jsonQuote(header.name), jsonQuote(header.value))
+	}
+	if req.bodyKind == snippetBodyForm {
+		b.WriteString("      \"Content-Type\": \"multipart/form-data; boundary=\" + form.boundary,\n")
+	}
+	b.WriteString("    },\n")
+	b.WriteString("  });\n\n")
+	b.WriteString("  console.log(res.status);\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderGoSnippet(req *snippetRequest) string {
+	imports := map[string]bool{"fmt": true, "io": true, "net/http": true}
+	var code strings.Builder
+	panicOnError := func(indent string) {
+		fmt.Fprintf(&code, "%sif err != nil {\n%s\tpanic(err)\n%s}\n", indent, indent, indent)
+	}
+
+	body := "nil"
+	switch req.bodyKind {
+	case snippetBodyText:
+		imports["strings"] = true
+		fmt.Fprintf(&code, "\tbody := strings.NewReader(%s)\n\n", strconv.Quote(req.body))
+		body = "body"
+	case snippetBodyFile:
+		imports["os"] = true
+		fmt.Fprintf(&code, "\tbody, err := os.Open(%s)\n", strconv.Quote(req.file))
+		panicOnError("\t")
+		code.WriteString("\tdefer body.Close()\n\n")
+		body = "body"
+	case snippetBodyForm:
+		imports["bytes"] = true
+		imports["mime/multipart"] = true
+		code.WriteString("\tbody := &bytes.Buffer{}\n")
+		code.WriteString("\twriter := multipart.NewWriter(body)\n")
+		for _, field := range req.form {
+			if !field.isFile && field.contentType == "" {
+				fmt.Fprintf(&code, "\tif err := writer.WriteField(%s, %s); err != nil {\n\t\tpanic(err)\n\t}\n",
+					strconv.Quote(field.name), strconv.Quote(field.value))
+				continue
+			}
+
+			code.WriteString("\t{\n")
+			disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(field.name))
+			if field.isFile {
+				disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(field.filename))
+			}
+			contentType := field.contentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			imports["net/textproto"] = true
+			code.WriteString("\t\theader := make(textproto.MIMEHeader)\n")
+			fmt.Fprintf(&code, "\t\theader.Set(\"Content-Disposition\", %s)\n", strconv.Quote(disposition))
+			fmt.Fprintf(&code, "\t\theader.Set(\"Content-Type\", %s)\n", strconv.Quote(contentType))
+			code.WriteString("\t\tpart, err := writer.CreatePart(header)\n")
+			panicOnError("\t\t")
+			if field.isFile {
+				imports["os"] = true
+				fmt.Fprintf(&code, "\t\tfile, err := os.Open(%s)\n", strconv.Quote(field.path))
+				panicOnError("\t\t")
+				code.WriteString("\t\tdefer file.Close()\n")
+				code.WriteString("\t\tif _, err := io.Copy(part, file); err != nil {\n\t\t\tpanic(err)\n\t\t}\n")
+			} else {
+				fmt.Fprintf(&code, "\t\tif _, err := io.WriteString(part, %s); err != nil {\n\t\t\tpanic(err)\n\t\t}\n", strconv.Quote(field.value))
+			}
+			code.WriteString("\t}\n")
+		}
+		code.WriteString("\tif err := writer.Close(); err != nil {\n\t\tpanic(err)\n\t}\n\n")
+		body = "body"
+	}
+
+	fmt.Fprintf(&code, "\treq, err := http.NewRequest(%s, %s, %s)\n", strconv.Quote(req.method), strconv.Quote(req.url), body)
+	panicOnError("\t")
+	for _, header := range req.headers {
+		// Go sends the Host header from the request, not from its header map
+		if strings.EqualFold(header.name, "Host") {
+			fmt.Fprintf(&code, "\treq.Host = %s\n", strconv.Quote(header.value))
+			continue
+		}
+		fmt.Fprintf(&code, "\treq.Header.Set(%s, %s)\n", strconv.Quote(header.name), strconv.Quote(header.value))
+	}
+	if req.bodyKind == snippetBodyForm {
+		code.WriteString("\treq.Header.Set(\"Content-Type\", writer.FormDataContentType())\n")
+	}
+
+	code.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	panicOnError("\t")
+	code.WriteString("\tdefer resp.Body.Close()\n\n")
+	code.WriteString("\trespBody, err := io.ReadAll(resp.Body)\n")
+	panicOnError("\t")
+	code.WriteString("\n\tfmt.Println(resp.Status)\n")
+	code.WriteString("\tfmt.Println(string(respBody))\n")
+
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n")
+	for _, path := range paths {
+		fmt.Fprintf(&b, "\t%s\n", strconv.Quote(path))
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+	b.WriteString(code.String())
+	b.WriteString("}\n")
+	return b.String()
+}
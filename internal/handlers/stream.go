@@ -0,0 +1,476 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"postman-runner/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamChunkSize is the most upstream body data read for a single chunk event
+const streamChunkSize = 32 * 1024
+
+var (
+	// errStreamTooLarge stops a relay once a single upstream event exceeds
+	// the response size limit
+	errStreamTooLarge = errors.New("event exceeds the maximum response size")
+
+	// errStreamMaxEvents stops a relay once it sent the maximum number of events
+	errStreamMaxEvents = errors.New("stream relayed the maximum number of events")
+)
+
+// executionStream is a streamed execution in progress
+type executionStream struct {
+	client context.Context // The caller's request context
+	ctx    context.Context // Cancelled when the stream ends for any reason
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	reason string // Why the stream was stopped before the upstream body ended
+}
+
+// stop ends the stream, keeping the first reason given
+func (s *executionStream) stop(reason string) {
+	s.mu.Lock()
+	if s.reason == "" {
+		s.reason = reason
+	}
+	s.mu.Unlock()
+	s.cancel()
+}
+
+func (s *executionStream) stopReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+// newExecutionStream starts a stream that ends when client is done or
+// maxDuration passes
+func newExecutionStream(client context.Context, maxDuration time.Duration) *executionStream {
+	ctx, cancel := context.WithTimeout(client, maxDuration)
+	return &executionStream{client: client, ctx: ctx, cancel: cancel}
+}
+
+// ExecuteStream handles POST /items/:id/execute/stream. The request is
+// prepared as for ExecuteRequest, then the upstream body is relayed to the
+// caller over Server-Sent Events as it arrives: a "start" event with the
+// status and headers, "chunk" events with raw body data, or "event" events
+// when upstream is itself an event stream, and a final "summary" event. The
+// stream ends after the maximum stream duration or number of events; the
+// response size limit only caps the body kept for assertions and history. The
+// stream is stopped through DELETE /executions/:id with the execution ID of
+// the "start" event. Retry policies do not apply to streamed executions.
+func (h *ExecutionHandler) ExecuteStream(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	item, execErr := h.fetchExecutableItem(itemID, "request")
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	execReq, variables, ok := h.bindExecutionRequest(c)
+	if !ok {
+		return
+	}
+
+	stream := newExecutionStream(c.Request.Context(), h.cfg.MaxStreamDuration)
+	defer stream.cancel()
+
+	record := newExecutionRecord(item, execReq)
+	prepared, execErr := h.prepareExecution(stream.ctx, item, execReq, variables, &record)
+	if execErr != nil {
+		h.recordExecution(&record, nil, execErr)
+		execErr.respond(c)
+		return
+	}
+	defer h.startExecution(&record, func() { stream.stop(models.StreamStopped) })()
+
+	// Errors before the response headers arrive are reported as for ExecuteRequest
+	startTime := time.Now()
+	resp, timer, closeIdle, err := h.openStream(stream, prepared)
+	defer closeIdle()
+	if err != nil {
+		h.saveCookies(prepared.opts, execReq)
+		record.DurationMs = time.Since(startTime).Milliseconds()
+		message := fmt.Sprintf("Failed to execute request: %v", err)
+		if reason := stream.stopReason(); reason != "" {
+			message = fmt.Sprintf("Failed to execute request: stream ended (%s) before the response headers arrived", reason)
+		}
+		execErr := &executionError{Status: http.StatusBadGateway, Code: "execution_error", Message: message}
+		record.Cancelled = stream.stopReason() == models.StreamStopped
+		h.recordExecution(&record, nil, execErr)
+		execErr.respond(c)
+		return
+	}
+	defer resp.Body.Close()
+
+	responseHeaders := make(map[string][]string, len(resp.Header))
+	for key, values := range resp.Header {
+		responseHeaders[key] = values
+	}
+
+	if record.ID != 0 {
+		c.Header(ExecutionIDHeader, strconv.Itoa(record.ID))
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	send := func(event string, data interface{}) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	send("start", models.StreamStart{ExecutionID: record.ID, Status: resp.StatusCode, Headers: responseHeaders})
+
+	result := h.relayBody(stream, resp, send)
+	timing := timer.timing(time.Now())
+	h.saveCookies(prepared.opts, execReq)
+
+	response := &models.ExecutionResponse{
+		Status:     resp.StatusCode,
+		Headers:    responseHeaders,
+		SizeBytes:  result.sizeBytes,
+		Truncated:  result.truncated,
+		DurationMs: time.Since(startTime).Milliseconds(),
+		Timing:     &timing,
+	}
+	if resp.TLS != nil {
+		response.TLS = describeTLS(resp.TLS, prepared.opts.tls != nil && prepared.opts.tls.skipVerify)
+	}
+	if isTextBody(resp.Header.Get("Content-Type"), result.body) {
+		response.Body = string(result.body)
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(result.body)
+		response.BodyEncoding = models.BodyEncodingBase64
+	}
+
+	if result.reason == models.StreamError {
+		execErr = &executionError{
+			Status:  http.StatusBadGateway,
+			Code:    "execution_error",
+			Message: fmt.Sprintf("Failed to read streamed response: %v", result.err),
+		}
+	} else {
+		h.processResponse(item, execReq, response)
+	}
+	record.Cancelled = result.reason == models.StreamStopped
+	h.recordExecution(&record, response, execErr)
+
+	summary := models.StreamSummary{
+		ExecutionID:        response.ExecutionID,
+		Status:             response.Status,
+		Reason:             result.reason,
+		Events:             result.events,
+		SizeBytes:          response.SizeBytes,
+		Truncated:          response.Truncated,
+		DurationMs:         response.DurationMs,
+		Timing:             response.Timing,
+		Assertions:         response.Assertions,
+		ExtractedVariables: response.ExtractedVariables,
+		ExtractionErrors:   response.ExtractionErrors,
+	}
+	if execErr != nil {
+		summary.Error = execErr.Message
+	}
+	if result.reason != models.StreamClientDisconnected {
+		send("summary", summary)
+	}
+}
+
+// openStream sends a prepared request within the stream's context. Only the
+// wait for the response headers is bound by the request timeout; the body
+// may take up to the stream's maximum duration. As for buffered executions,
+// a digest challenge is answered once and a rejected OAuth 2.0 token is
+// dropped from the cache.
+func (h *ExecutionHandler) openStream(stream *executionStream, prepared *preparedRequest) (*http.Response, *requestTimer, func(), error) {
+	client, closeIdle := h.newHTTPClient(prepared.opts)
+	req := prepared.req
+
+	send := func() (*http.Response, *requestTimer, error) {
+		httpReq, err := newOutboundRequest(stream.ctx, req.Method, req.URL, req.Headers, req.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		timer := newRequestTimer()
+		httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), timer.trace()))
+
+		headerTimeout := time.AfterFunc(h.cfg.RequestTimeout, func() { stream.stop(models.StreamTimeout) })
+		resp, err := client.Do(httpReq)
+		headerTimeout.Stop()
+		if err != nil {
+			return nil, nil, fmt.Errorf("request failed: %w", err)
+		}
+		return resp, timer, nil
+	}
+
+	resp, timer, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || prepared.auth == nil {
+		return resp, timer, closeIdle, err
+	}
+
+	switch prepared.auth.Type {
+	case models.AuthTypeDigest:
+		authorization, ok, err := digestAuthorization(resp.Header.Values("Www-Authenticate"), req, prepared.auth.Params)
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, closeIdle, fmt.Errorf("digest auth: %w", err)
+		}
+		if ok {
+			// Drain the challenge so its connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, h.cfg.MaxResponseSize))
+			resp.Body.Close()
+			setHeader(req.Headers, "Authorization", authorization)
+			resp, timer, err = send()
+		}
+
+	case models.AuthTypeOAuth2:
+		if usesClientCredentials(prepared.auth.Params) {
+			oauth2Tokens.evict(oauth2CacheKey(prepared.auth.Params))
+		}
+	}
+	return resp, timer, closeIdle, err
+}
+
+// streamResult is the outcome of relaying an upstream body
+type streamResult struct {
+	body      []byte // Up to the response size limit, kept for the history
+	sizeBytes int64  // All of the body relayed
+	truncated bool
+	events    int
+	reason    string
+	err       error // Set for the error reason
+}
+
+// collect accounts for body data relayed from upstream, keeping it for the
+// history up to limit
+func (r *streamResult) collect(p []byte, limit int64) {
+	r.sizeBytes += int64(len(p))
+	if room := limit - int64(len(r.body)); int64(len(p)) > room {
+		p = p[:room]
+		r.truncated = true
+	}
+	r.body = append(r.body, p...)
+}
+
+// sent counts an event sent to the caller and reports whether the stream
+// may go on
+func (r *streamResult) sent(maxEvents int) bool {
+	r.events++
+	return maxEvents == 0 || r.events < maxEvents
+}
+
+// relayBody relays the upstream body until it ends or the stream is stopped
+func (h *ExecutionHandler) relayBody(stream *executionStream, resp *http.Response, send func(string, interface{})) streamResult {
+	var result streamResult
+
+	var err error
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/event-stream" {
+		err = h.relayEvents(resp.Body, &result, send)
+	} else {
+		err = h.relayChunks(resp.Body, contentType, &result, send)
+	}
+
+	switch {
+	case err == nil:
+		result.reason = models.StreamCompleted
+	case errors.Is(err, errStreamTooLarge):
+		result.reason = models.StreamMaxSize
+	case errors.Is(err, errStreamMaxEvents):
+		result.reason = models.StreamMaxEvents
+	case stream.stopReason() != "":
+		result.reason = stream.stopReason()
+	case stream.client.Err() != nil:
+		result.reason = models.StreamClientDisconnected
+	case errors.Is(stream.ctx.Err(), context.DeadlineExceeded):
+		result.reason = models.StreamTimeout
+	default:
+		result.reason = models.StreamError
+		result.err = err
+	}
+	return result
+}
+
+// relayChunks sends body data as "chunk" events as it is read. Text is kept
+// valid by holding back a UTF-8 sequence split across reads; binary bodies
+// are sent base64-encoded, each chunk on its own.
+func (h *ExecutionHandler) relayChunks(body io.Reader, contentType string, result *streamResult, send func(string, interface{})) error {
+	buf := make([]byte, streamChunkSize)
+	var pending []byte
+	isText := -1 // Decided on the first data
+
+	emit := func(data []byte) bool {
+		if len(data) == 0 {
+			return true
+		}
+		chunk := models.StreamChunk{Data: string(data)}
+		if isText == 0 {
+			chunk = models.StreamChunk{Data: base64.StdEncoding.EncodeToString(data), Encoding: models.BodyEncodingBase64}
+		}
+		send("chunk", chunk)
+		return result.sent(h.cfg.MaxStreamEvents)
+	}
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			data := buf[:n]
+			result.collect(data, h.cfg.MaxResponseSize)
+			if isText < 0 {
+				isText = 0
+				if isTextBody(contentType, data) {
+					isText = 1
+				}
+			}
+			if isText == 1 {
+				data = append(pending, data...)
+				cut := completeUTF8Prefix(data)
+				pending = append([]byte(nil), data[cut:]...)
+				data = data[:cut]
+			}
+			if !emit(data) {
+				return errStreamMaxEvents
+			}
+		}
+		if err == io.EOF {
+			emit(pending)
+			return nil
+		}
+		if err != nil {
+			emit(pending)
+			return err
+		}
+	}
+}
+
+// completeUTF8Prefix returns the length of p without a trailing incomplete
+// UTF-8 sequence
+func completeUTF8Prefix(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return len(p)
+			}
+			return i
+		}
+	}
+	return len(p)
+}
+
+// relayEvents parses an upstream event stream and sends each event as an
+// "event" event once it is complete. Comments are dropped and an event left
+// unterminated at the end of the body is discarded, as an EventSource would.
+func (h *ExecutionHandler) relayEvents(body io.Reader, result *streamResult, send func(string, interface{})) error {
+	lines := &eventStreamReader{reader: bufio.NewReader(body), maxLine: h.cfg.MaxResponseSize}
+	var event models.StreamEvent
+	var data []string
+	var dataSize int64
+
+	for {
+		raw, line, err := lines.readLine()
+		result.collect(raw, h.cfg.MaxResponseSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "":
+			// A blank line dispatches the event; events without data are not dispatched
+			if len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				send("event", event)
+				if !result.sent(h.cfg.MaxStreamEvents) {
+					return errStreamMaxEvents
+				}
+			}
+			event, data, dataSize = models.StreamEvent{}, nil, 0
+		case strings.HasPrefix(line, ":"):
+			// Comment, often used as a keep-alive
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.Event = value
+			case "data":
+				if dataSize += int64(len(value)) + 1; dataSize > h.cfg.MaxResponseSize {
+					return errStreamTooLarge
+				}
+				data = append(data, value)
+			case "id":
+				if !strings.Contains(value, "\x00") {
+					event.ID = value
+				}
+			case "retry":
+				if retry, err := strconv.Atoi(value); err == nil && retry >= 0 {
+					event.Retry = &retry
+				}
+			}
+		}
+	}
+}
+
+// eventStreamReader reads the lines of an event stream, which may end in
+// "\r\n", "\n" or a bare "\r"
+type eventStreamReader struct {
+	reader  *bufio.Reader
+	maxLine int64
+	afterCR bool // The last line ended in "\r", so a "\n" next belongs to it
+}
+
+// readLine returns the bytes read, for the history, and the line they end
+// without its line break. A line left unterminated at the end of the body
+// is dropped with io.EOF; one longer than maxLine ends the relay with
+// errStreamTooLarge.
+func (r *eventStreamReader) readLine() ([]byte, string, error) {
+	var raw []byte
+	start := 0
+	for {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return raw, "", err
+		}
+		raw = append(raw, b)
+
+		switch {
+		case b == '\n' && r.afterCR && len(raw) == 1:
+			start = 1
+			r.afterCR = false
+		case b == '\n' || b == '\r':
+			r.afterCR = b == '\r'
+			return raw, string(raw[start : len(raw)-1]), nil
+		default:
+			r.afterCR = false
+			if int64(len(raw)-start) > r.maxLine {
+				return raw, "", errStreamTooLarge
+			}
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"postman-runner/internal/models"
+)
+
+// tlsSettings is the TLS material that applies to an execution
+type tlsSettings struct {
+	skipVerify   bool
+	certificates []tlsCertificate // Environment certificates first, then global ones
+}
+
+type tlsCertificate struct {
+	hostPattern string
+	clientCert  *tls.Certificate
+	caCerts     []*x509.Certificate
+}
+
+// loadTLSSettings reads the certificates that apply to an execution made with
+// environmentID, which may be nil, along with the environment's verification
+// setting. Private keys are decrypted with encryptionKey.
+func loadTLSSettings(db *sql.DB, environmentID *int, encryptionKey []byte) (*tlsSettings, error) {
+	settings := &tlsSettings{}
+
+	if environmentID != nil {
+		err := db.QueryRow("SELECT tls_skip_verify FROM environments WHERE id = $1", *environmentID).Scan(&settings.skipVerify)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	rows, err := db.Query(`
+		SELECT id, host_pattern, client_cert, client_key, ca_cert
+		FROM certificates
+		WHERE environment_id IS NULL OR environment_id = $1
+		ORDER BY environment_id NULLS LAST, id
+	`, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var cert tlsCertificate
+		var clientCert, clientKey, caCert sql.NullString
+		if err := rows.Scan(&id, &cert.hostPattern, &clientCert, &clientKey, &caCert); err != nil {
+			return nil, err
+		}
+
+		// Certificates are validated when saved, so failures here are unexpected
+		if clientCert.Valid && clientKey.Valid {
+			keyPEM, err := openClientKey(encryptionKey, clientKey.String)
+			if err != nil {
+				log.Printf("Skipping certificate %d: %v", id, err)
+				continue
+			}
+			pair, err := tls.X509KeyPair([]byte(clientCert.String), []byte(keyPEM))
+			if err != nil {
+				log.Printf("Skipping certificate %d: invalid client certificate: %v", id, err)
+				continue
+			}
+			cert.clientCert = &pair
+		}
+		if caCert.Valid {
+			cert.caCerts, err = parseCertificatesPEM(caCert.String)
+			if err != nil {
+				log.Printf("Skipping certificate %d: invalid CA bundle: %v", id, err)
+				continue
+			}
+		}
+		settings.certificates = append(settings.certificates, cert)
+	}
+
+	return settings, rows.Err()
+}
+
+// isDefault reports whether the settings leave TLS as the default transport does
+func (s *tlsSettings) isDefault() bool {
+	return s == nil || (!s.skipVerify && len(s.certificates) == 0)
+}
+
+// configFor builds the TLS configuration for requests to u. The first
+// matching client certificate is presented; CA certificates of every
+// matching entry are trusted in addition to the system roots.
+func (s *tlsSettings) configFor(u *url.URL) *tls.Config {
+	config := &tls.Config{InsecureSkipVerify: s.skipVerify}
+
+	var roots *x509.CertPool
+	for _, cert := range s.certificates {
+		if !matchHostPattern(cert.hostPattern, u) {
+			continue
+		}
+		if cert.clientCert != nil && config.Certificates == nil {
+			config.Certificates = []tls.Certificate{*cert.clientCert}
+		}
+		if len(cert.caCerts) > 0 {
+			if roots == nil {
+				var err error
+				if roots, err = x509.SystemCertPool(); err != nil {
+					roots = x509.NewCertPool()
+				}
+			}
+			for _, ca := range cert.caCerts {
+				roots.AddCert(ca)
+			}
+		}
+	}
+	config.RootCAs = roots
+
+	return config
+}
+
+// hostTransport sends each request through a transport configured for its
+// host, so that client certificates are only presented to the hosts they are
+// meant for, including across redirects. Every transport routes through the
+// proxy, if any.
+type hostTransport struct {
+	settings *tlsSettings
+	proxy    func(*http.Request) (*url.URL, error)
+	base     *http.Transport // Cloned for each host; http.DefaultTransport if nil
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+func newHostTransport(settings *tlsSettings, proxy *proxySettings) *hostTransport {
+	t := &hostTransport{settings: settings, transports: make(map[string]*http.Transport)}
+	if proxy != nil {
+		t.proxy = proxy.proxyFunc()
+	}
+	return t
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.Scheme + "://" + req.URL.Host
+
+	t.mu.Lock()
+	transport, ok := t.transports[key]
+	if !ok {
+		base := t.base
+		if base == nil {
+			base = http.DefaultTransport.(*http.Transport)
+		}
+		transport = base.Clone()
+		if !t.settings.isDefault() {
+			transport.TLSClientConfig = t.settings.configFor(req.URL)
+		}
+		if t.proxy != nil {
+			transport.Proxy = t.proxy
+		}
+		t.transports[key] = transport
+	}
+	t.mu.Unlock()
+
+	return transport.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the connections of every per-host transport
+func (t *hostTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// matchHostPattern reports whether u's host matches pattern, which is a host
+// name, optionally with a port, whose first label may be "*" to match any
+// subdomain
+func matchHostPattern(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	host := canonicalHost(u)
+
+	patternHost, patternPort := pattern, ""
+	if h, p, err := net.SplitHostPort(pattern); err == nil {
+		patternHost, patternPort = h, p
+	}
+	if patternPort != "" && patternPort != urlPort(u) {
+		return false
+	}
+
+	if strings.HasPrefix(patternHost, "*.") {
+		return strings.HasSuffix(host, patternHost[1:])
+	}
+	return host == patternHost
+}
+
+// urlPort returns the port of u, falling back to the scheme's default
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		return "443"
+	}
+	return "80"
+}
+
+// validateHostPattern checks the syntax accepted by matchHostPattern
+func validateHostPattern(pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("host_pattern is required")
+	}
+	if strings.Contains(pattern, "://") || strings.ContainsAny(pattern, "/?# ") {
+		return fmt.Errorf("host_pattern must be a host name, optionally with a port, not a URL")
+	}
+	host := pattern
+	if h, _, err := net.SplitHostPort(pattern); err == nil {
+		host = h
+	}
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return fmt.Errorf("host_pattern may only use '*' as its first label")
+	}
+	return nil
+}
+
+// parseCertificatesPEM decodes every certificate in a PEM bundle
+func parseCertificatesPEM(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// describeTLS reports the negotiated parameters and peer chain of a connection
+func describeTLS(state *tls.ConnectionState, verificationDisabled bool) *models.TLSInfo {
+	info := &models.TLSInfo{
+		Version:              tls.VersionName(state.Version),
+		CipherSuite:          tls.CipherSuiteName(state.CipherSuite),
+		ServerName:           state.ServerName,
+		NegotiatedProtocol:   state.NegotiatedProtocol,
+		VerificationDisabled: verificationDisabled,
+		PeerCertificates:     make([]models.PeerCertificate, 0, len(state.PeerCertificates)),
+	}
+	for _, cert := range state.PeerCertificates {
+		fingerprint := sha256.Sum256(cert.Raw)
+		info.PeerCertificates = append(info.PeerCertificates, models.PeerCertificate{
+			Subject:           cert.Subject.String(),
+			Issuer:            cert.Issuer.String(),
+			SerialNumber:      cert.SerialNumber.String(),
+			NotBefore:         cert.NotBefore,
+			NotAfter:          cert.NotAfter,
+			DNSNames:          cert.DNSNames,
+			SHA256Fingerprint: hex.EncodeToString(fingerprint[:]),
+		})
+	}
+	return info
+}
@@ -25,14 +25,16 @@ func (h *CollectionHandler) GetCollectionTree(c *gin.Context) {
 
 	// Get collection info
 	var collection models.Collection
+	var authJSON []byte
 	err = h.db.QueryRow(`
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, auth, created_at, updated_at
 		FROM collections
 		WHERE id = $1
 	`, collectionID).Scan(
 		&collection.ID,
 		&collection.Name,
 		&collection.Description,
+		&authJSON,
 		&collection.CreatedAt,
 		&collection.UpdatedAt,
 	)
@@ -51,13 +53,39 @@ func (h *CollectionHandler) GetCollectionTree(c *gin.Context) {
 		return
 	}
 
+	collection.Auth = parseAuth(authJSON)
+
+	// Get all items in tree order
+	flatItems, err := fetchCollectionItems(h.db, collectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch collection items",
+		})
+		return
+	}
+
+	// Convert to tree structure
+	tree := buildTree(flatItems)
+
+	response := models.CollectionTree{
+		Collection: collection,
+		Items:      tree,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchCollectionItems returns every item of a collection as a flat list in
+// tree order: parents precede their children and siblings follow sort_order
+func fetchCollectionItems(db *sql.DB, collectionID int) ([]models.CollectionItem, error) {
 	// Get all items using recursive CTE
-	rows, err := h.db.Query(`
+	rows, err := db.Query(`
 		WITH RECURSIVE item_tree AS (
 			-- Base case: root level items (no parent)
 			SELECT 
 				id, collection_id, parent_id, name, item_type, 
-				sort_order, method, url, headers, body, extraction_rules,
+				sort_order, method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy, messages,
 				ARRAY[sort_order] as path
 			FROM collection_items
 			WHERE collection_id = $1 AND parent_id IS NULL
@@ -67,33 +95,30 @@ func (h *CollectionHandler) GetCollectionTree(c *gin.Context) {
 			-- Recursive case: child items
 			SELECT 
 				ci.id, ci.collection_id, ci.parent_id, ci.name, ci.item_type,
-				ci.sort_order, ci.method, ci.url, ci.headers, ci.body, ci.extraction_rules,
+				ci.sort_order, ci.method, ci.url, ci.headers, ci.body, ci.body_mode, ci.body_data, ci.extraction_rules, ci.assertions, ci.auth, ci.retry_policy, ci.messages,
 				it.path || ci.sort_order
 			FROM collection_items ci
 			INNER JOIN item_tree it ON ci.parent_id = it.id
 		)
 		SELECT 
-			id, parent_id, name, item_type, sort_order, 
-			method, url, headers, body, extraction_rules
+			id, collection_id, parent_id, name, item_type, sort_order, 
+			method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy, messages
 		FROM item_tree
 		ORDER BY path
 	`, collectionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to fetch collection items",
-		})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	// Build flat list first
+	// Build flat list
 	var flatItems []models.CollectionItem
 	for rows.Next() {
 		var item models.CollectionItem
-		var extractionRulesJSON []byte
+		var bodyDataJSON, extractionRulesJSON, assertionsJSON, authJSON, retryPolicyJSON, messagesJSON []byte
 		err := rows.Scan(
 			&item.ID,
+			&item.CollectionID,
 			&item.ParentID,
 			&item.Name,
 			&item.ItemType,
@@ -102,33 +127,37 @@ func (h *CollectionHandler) GetCollectionTree(c *gin.Context) {
 			&item.URL,
 			&item.Headers,
 			&item.Body,
+			&item.BodyMode,
+			&bodyDataJSON,
 			&extractionRulesJSON,
+			&assertionsJSON,
+			&authJSON,
+			&retryPolicyJSON,
+			&messagesJSON,
 		)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "database_error",
-				Message: "Failed to scan collection item",
-			})
-			return
+			return nil, err
 		}
 
+		item.BodyData = parseBodyData(bodyDataJSON)
+		item.Auth = parseAuth(authJSON)
+		item.RetryPolicy = parseRetryPolicy(retryPolicyJSON)
+		item.Messages = parseWebSocketMessages(messagesJSON)
+
 		// Parse extraction_rules
 		if err := json.Unmarshal(extractionRulesJSON, &item.ExtractionRules); err != nil {
 			item.ExtractionRules = []models.ExtractionRule{}
 		}
 
-		flatItems = append(flatItems, item)
-	}
-
-	// Convert to tree structure
-	tree := buildTree(flatItems)
+		// Parse assertions
+		if err := json.Unmarshal(assertionsJSON, &item.Assertions); err != nil {
+			item.Assertions = []models.Assertion{}
+		}
 
-	response := models.CollectionTree{
-		Collection: collection,
-		Items:      tree,
+		flatItems = append(flatItems, item)
 	}
 
-	c.JSON(http.StatusOK, response)
+	return flatItems, rows.Err()
 }
 
 func buildTree(items []models.CollectionItem) []models.ItemTreeNode {
@@ -161,11 +190,31 @@ func buildTree(items []models.CollectionItem) []models.ItemTreeNode {
 			if item.Body.Valid {
 				node.Body = item.Body.String
 			}
+			node.BodyMode = item.BodyMode
+			node.BodyData = item.BodyData
+			node.RetryPolicy = item.RetryPolicy
+		}
+
+		// Add websocket-specific fields
+		if item.ItemType == "websocket" {
+			if item.URL.Valid {
+				node.URL = item.URL.String
+			}
+			if item.Headers.Valid {
+				node.Headers = item.Headers.String
+			}
+			node.Messages = item.Messages
 		}
 
 		// Add extraction rules
 		node.ExtractionRules = item.ExtractionRules
 
+		// Add assertions
+		node.Assertions = item.Assertions
+
+		// Add auth, which folders can carry as well
+		node.Auth = item.Auth
+
 		nodeMap[item.ID] = &node
 
 		if !item.ParentID.Valid || item.ParentID.Int64 == 0 {
@@ -251,11 +300,11 @@ func (h *CollectionHandler) GetItem(c *gin.Context) {
 	}
 
 	var item models.CollectionItem
-	var extractionRulesJSON []byte
+	var bodyDataJSON, extractionRulesJSON, assertionsJSON, authJSON, retryPolicyJSON, messagesJSON []byte
 	err = h.db.QueryRow(`
 		SELECT 
 			id, collection_id, parent_id, name, item_type, 
-			sort_order, method, url, headers, body, extraction_rules, created_at, updated_at
+			sort_order, method, url, headers, body, body_mode, body_data, extraction_rules, assertions, auth, retry_policy, messages, created_at, updated_at
 		FROM collection_items
 		WHERE id = $1
 	`, itemID).Scan(
@@ -269,7 +318,13 @@ func (h *CollectionHandler) GetItem(c *gin.Context) {
 		&item.URL,
 		&item.Headers,
 		&item.Body,
+		&item.BodyMode,
+		&bodyDataJSON,
 		&extractionRulesJSON,
+		&assertionsJSON,
+		&authJSON,
+		&retryPolicyJSON,
+		&messagesJSON,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -302,6 +357,11 @@ func (h *CollectionHandler) GetItem(c *gin.Context) {
 		item.ExtractionRules = []models.ExtractionRule{}
 	}
 
+	// Parse assertions
+	if err := json.Unmarshal(assertionsJSON, &item.Assertions); err != nil {
+		item.Assertions = []models.Assertion{}
+	}
+
 	response := gin.H{
 		"id":            item.ID,
 		"collection_id": item.CollectionID,
@@ -316,6 +376,10 @@ func (h *CollectionHandler) GetItem(c *gin.Context) {
 		response["parent_id"] = item.ParentID.Int64
 	}
 
+	if auth := parseAuth(authJSON); auth != nil {
+		response["auth"] = auth
+	}
+
 	if item.ItemType == "request" {
 		if item.Method.Valid {
 			response["method"] = item.Method.String
@@ -329,7 +393,29 @@ func (h *CollectionHandler) GetItem(c *gin.Context) {
 		if item.Body.Valid {
 			response["body"] = item.Body.String
 		}
+		response["body_mode"] = item.BodyMode
+		if bodyData := parseBodyData(bodyDataJSON); bodyData != nil {
+			response["body_data"] = bodyData
+		}
 		response["extraction_rules"] = item.ExtractionRules
+		response["assertions"] = item.Assertions
+		if retryPolicy := parseRetryPolicy(retryPolicyJSON); retryPolicy != nil {
+			response["retry_policy"] = retryPolicy
+		}
+	}
+
+	if item.ItemType == "websocket" {
+		if item.URL.Valid {
+			response["url"] = item.URL.String
+		}
+		if headers != nil {
+			response["headers"] = headers
+		}
+		messages := parseWebSocketMessages(messagesJSON)
+		if messages == nil {
+			messages = []models.WebSocketMessage{}
+		}
+		response["messages"] = messages
 	}
 
 	c.JSON(http.StatusOK, response)
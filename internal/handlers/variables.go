@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variablePattern matches Postman-style {{variable}} placeholders
+var variablePattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// variableResolver substitutes {{variable}} placeholders and records any
+// placeholder that has no matching variable
+type variableResolver struct {
+	variables  map[string]string
+	unresolved map[string]bool
+}
+
+func newVariableResolver(variables map[string]string) *variableResolver {
+	if variables == nil {
+		variables = make(map[string]string)
+	}
+	return &variableResolver{
+		variables:  variables,
+		unresolved: make(map[string]bool),
+	}
+}
+
+// Resolve replaces every known placeholder in s with its value
+func (r *variableResolver) Resolve(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := r.variables[name]; ok {
+			return value
+		}
+		r.unresolved[name] = true
+		return match
+	})
+}
+
+// Unresolved returns the sorted names of placeholders that could not be resolved
+func (r *variableResolver) Unresolved() []string {
+	names := make([]string, 0, len(r.unresolved))
+	for name := range r.unresolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadEnvironmentVariables fetches the variables of an environment.
+// Returns sql.ErrNoRows if the environment does not exist.
+func loadEnvironmentVariables(db *sql.DB, environmentID int) (map[string]string, error) {
+	var variablesJSON []byte
+	err := db.QueryRow("SELECT variables FROM environments WHERE id = $1", environmentID).Scan(&variablesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]string)
+	if err := json.Unmarshal(variablesJSON, &variables); err != nil {
+		variables = make(map[string]string)
+	}
+	return variables, nil
+}
+
+// mergeEnvironmentVariables updates existing and adds new variables on an
+// environment and returns the merged set. Returns sql.ErrNoRows if the
+// environment does not exist.
+func mergeEnvironmentVariables(db *sql.DB, environmentID int, updates map[string]string) (map[string]string, error) {
+	updatesJSON, err := json.Marshal(updates)
+	if err != nil {
+		return nil, err
+	}
+
+	var mergedJSON []byte
+	err = db.QueryRow(`
+		UPDATE environments
+		SET variables = COALESCE(variables, '{}'::jsonb) || $1::jsonb, updated_at = NOW()
+		WHERE id = $2
+		RETURNING variables
+	`, string(updatesJSON), environmentID).Scan(&mergedJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string)
+	if err := json.Unmarshal(mergedJSON, &merged); err != nil {
+		merged = make(map[string]string)
+	}
+	return merged, nil
+}
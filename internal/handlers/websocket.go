@@ -0,0 +1,645 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"postman-runner/internal/models"
+	"postman-runner/internal/validator"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// maxWebSocketLogEntries caps the frames kept in the log of a connection
+const maxWebSocketLogEntries = 1000
+
+// websocketFrame is a message relayed between the caller and upstream
+type websocketFrame struct {
+	payloadType byte
+	data        []byte
+}
+
+// frameCodec sends and receives frames as they are, keeping their payload type
+var frameCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		frame := v.(*websocketFrame)
+		return frame.data, frame.payloadType, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		frame := v.(*websocketFrame)
+		frame.data, frame.payloadType = data, payloadType
+		return nil
+	},
+}
+
+// validateWebSocketURL checks the URL of a websocket item. URLs built from
+// {{variables}} are checked once resolved, when connecting.
+func validateWebSocketURL(urlStr string) error {
+	if urlStr == "" {
+		return fmt.Errorf("url is required for websocket items")
+	}
+	if strings.Contains(urlStr, "{{") {
+		return nil
+	}
+	_, err := validator.ParseWebSocketURL(urlStr)
+	return err
+}
+
+// validateWebSocketMessages checks the saved messages of a websocket item
+func validateWebSocketMessages(messages []models.WebSocketMessage) error {
+	names := make(map[string]bool, len(messages))
+	for i, message := range messages {
+		if message.Name == "" {
+			return fmt.Errorf("message %d: name is required", i)
+		}
+		if names[message.Name] {
+			return fmt.Errorf("message %d: duplicate name %q", i, message.Name)
+		}
+		names[message.Name] = true
+
+		switch message.Type {
+		case "", models.WebSocketText:
+		case models.WebSocketBinary:
+			if _, err := base64.StdEncoding.DecodeString(message.Data); err != nil {
+				return fmt.Errorf("message %q: binary data must be base64-encoded", message.Name)
+			}
+		default:
+			return fmt.Errorf("message %q: type must be 'text' or 'binary'", message.Name)
+		}
+	}
+	return nil
+}
+
+// parseWebSocketMessages decodes a messages JSONB column
+func parseWebSocketMessages(data []byte) []models.WebSocketMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var messages []models.WebSocketMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil
+	}
+	return messages
+}
+
+// encodeWebSocketMessages serializes messages for a messages JSONB column.
+// An empty list is stored as NULL.
+func encodeWebSocketMessages(messages []models.WebSocketMessage) (interface{}, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// ConnectWebSocket handles GET /items/:id/connect, which must be a WebSocket
+// upgrade. The item's upstream connection is opened first; once it is, the
+// caller's connection is upgraded and frames are relayed both ways as they
+// are, until either side closes or the connection outlives the maximum
+// stream duration. The optional environment_id query parameter selects the
+// environment, and each send parameter names a saved message to send
+// upstream once connected. Frames and connection events are kept in the
+// execution history, whose ID is sent in the X-Execution-ID header of the
+// upgrade response; DELETE /executions/:id closes the connection.
+func (h *ExecutionHandler) ConnectWebSocket(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID must be a valid integer",
+		})
+		return
+	}
+
+	if !c.IsWebsocket() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "websocket_required",
+			Message: "This endpoint must be called with a WebSocket upgrade request",
+		})
+		return
+	}
+
+	// CORS does not apply to WebSocket upgrades, so browsers are held to the
+	// same origins here; without this any page could read the frames of a
+	// connection dialed with the environment's credentials
+	if err := h.checkWebSocketOrigin(c.Request); err != nil {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "origin_not_allowed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	item, execErr := h.fetchExecutableItem(itemID, "websocket")
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	var execReq ExecutionRequest
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		envID, err := strconv.Atoi(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_id",
+				Message: "environment_id must be a valid integer",
+			})
+			return
+		}
+		execReq.EnvironmentID = &envID
+	}
+
+	variables, ok := h.loadExecutionVariables(c, execReq)
+	if !ok {
+		return
+	}
+
+	outgoing, execErr := savedMessageFrames(item.Messages, c.QueryArray("send"), variables)
+	if execErr != nil {
+		execErr.respond(c)
+		return
+	}
+
+	// The upgrade is an HTTP GET, prepared like any other request
+	upgrade := *item
+	upgrade.Method = sql.NullString{String: http.MethodGet, Valid: true}
+	upgrade.Body = sql.NullString{}
+	upgrade.BodyMode = models.BodyModeRaw
+
+	record := newExecutionRecord(item, execReq)
+	prepared, execErr := h.prepareExecution(c.Request.Context(), &upgrade, execReq, variables, &record)
+	if execErr != nil {
+		h.recordExecution(&record, nil, execErr)
+		execErr.respond(c)
+		return
+	}
+
+	ctx, cancel, release := cancellable(c.Request.Context())
+	defer release()
+	defer h.startExecution(&record, cancel)()
+
+	startTime := time.Now()
+	upstream, err := h.dialWebSocket(ctx, prepared)
+	if err != nil {
+		record.DurationMs = time.Since(startTime).Milliseconds()
+		execErr := &executionError{
+			Status:  http.StatusBadGateway,
+			Code:    "execution_error",
+			Message: fmt.Sprintf("Failed to connect: %v", err),
+		}
+		h.recordExecution(&record, nil, execErr)
+		execErr.respond(c)
+		return
+	}
+	h.saveCookies(prepared.opts, execReq)
+
+	session := newWebSocketSession(h.cfg.MaxStoredResponseSize)
+	session.event(models.WebSocketOpen, "Connected to "+record.URL)
+
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			if err := h.checkWebSocketOrigin(req); err != nil {
+				return err
+			}
+			// The upgrade response is written by the handshake, not gin
+			if record.ID != 0 {
+				config.Header = http.Header{ExecutionIDHeader: {strconv.Itoa(record.ID)}}
+			}
+			return nil
+		},
+		Handler: func(client *websocket.Conn) {
+			client.MaxPayloadBytes = int(h.cfg.MaxResponseSize)
+			h.relayWebSocket(ctx, client, upstream, outgoing, session)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+
+	// The handler does not run if the caller's handshake was invalid
+	session.end("The caller's WebSocket handshake failed", nil)
+	upstream.Close()
+
+	response := &models.ExecutionResponse{
+		Status:     http.StatusSwitchingProtocols,
+		SizeBytes:  session.receivedBytes,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	record.WebSocketLog = session.finish()
+	record.Cancelled = wasCancelled(ctx)
+	if session.err != nil {
+		execErr = &executionError{
+			Status:  http.StatusBadGateway,
+			Code:    "execution_error",
+			Message: fmt.Sprintf("WebSocket connection failed: %v", session.err),
+		}
+	}
+	h.recordExecution(&record, response, execErr)
+}
+
+// checkWebSocketOrigin rejects upgrades from browser origins that CORS does
+// not allow. Requests without an Origin header do not come from a browser.
+func (h *ExecutionHandler) checkWebSocketOrigin(req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || h.cfg.OriginAllowed(origin) {
+		return nil
+	}
+	return fmt.Errorf("origin '%s' is not allowed", origin)
+}
+
+// savedMessageFrames builds the frames of the saved messages named in send,
+// in order, resolving {{variables}} in text messages
+func savedMessageFrames(messages []models.WebSocketMessage, send []string, variables map[string]string) ([]websocketFrame, *executionError) {
+	saved := make(map[string]models.WebSocketMessage, len(messages))
+	for _, message := range messages {
+		saved[message.Name] = message
+	}
+
+	resolver := newVariableResolver(variables)
+	frames := make([]websocketFrame, 0, len(send))
+	for _, name := range send {
+		message, ok := saved[name]
+		if !ok {
+			return nil, &executionError{
+				Status:  http.StatusBadRequest,
+				Code:    "unknown_message",
+				Message: fmt.Sprintf("Item has no saved message named %q", name),
+			}
+		}
+
+		if message.Type == models.WebSocketBinary {
+			// Validated when saved
+			data, _ := base64.StdEncoding.DecodeString(message.Data)
+			frames = append(frames, websocketFrame{payloadType: websocket.BinaryFrame, data: data})
+			continue
+		}
+		frames = append(frames, websocketFrame{payloadType: websocket.TextFrame, data: []byte(resolver.Resolve(message.Data))})
+	}
+
+	if unresolved := resolver.Unresolved(); len(unresolved) > 0 {
+		return nil, &executionError{
+			Status:    http.StatusBadRequest,
+			Code:      "unresolved_variables",
+			Message:   fmt.Sprintf("Messages reference undefined variables: %s", strings.Join(unresolved, ", ")),
+			Variables: unresolved,
+		}
+	}
+	return frames, nil
+}
+
+// dialWebSocket opens the upstream connection of a prepared upgrade request.
+// The Origin and Sec-WebSocket-Protocol headers are taken from the request
+// headers; the origin defaults to the target's HTTP origin. The environment's
+// cookies, the certificates matching the host and the proxy are used as for
+// HTTP requests, cookies set by the handshake response are stored in the
+// jar, and the handshake is bound by the request timeout.
+func (h *ExecutionHandler) dialWebSocket(ctx context.Context, prepared *preparedRequest) (*websocket.Conn, error) {
+	req, opts := prepared.req, prepared.opts
+
+	target, err := validator.ParseWebSocketURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	httpURL := *target
+	httpURL.Scheme = "http"
+	if strings.EqualFold(target.Scheme, "wss") {
+		httpURL.Scheme = "https"
+	}
+
+	origin := httpURL.Scheme + "://" + httpURL.Host
+	header := make(http.Header)
+	var protocols []string
+	for key, value := range req.Headers {
+		switch http.CanonicalHeaderKey(key) {
+		case "Origin":
+			origin = value
+		case "Sec-Websocket-Protocol":
+			for _, protocol := range strings.Split(value, ",") {
+				if protocol = strings.TrimSpace(protocol); protocol != "" {
+					protocols = append(protocols, protocol)
+				}
+			}
+		default:
+			header.Set(key, value)
+		}
+	}
+
+	if opts.jar != nil {
+		cookies := opts.jar.Cookies(&httpURL)
+		if len(cookies) > 0 {
+			pairs := make([]string, 0, len(cookies)+1)
+			if existing := header.Get("Cookie"); existing != "" {
+				pairs = append(pairs, existing)
+			}
+			for _, cookie := range cookies {
+				pairs = append(pairs, cookie.Name+"="+cookie.Value)
+			}
+			header.Set("Cookie", strings.Join(pairs, "; "))
+		}
+	}
+
+	config, err := websocket.NewConfig(target.String(), origin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+	config.Header = header
+	config.Protocol = protocols
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.RequestTimeout)
+	defer cancel()
+	conn, err := h.dialWebSocketConn(ctx, &httpURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	recorder := &handshakeRecorder{Conn: conn}
+	ws, err := websocket.NewClient(config, recorder)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if opts.jar != nil {
+		if response := recorder.response(); response != nil {
+			opts.jar.SetCookies(&httpURL, response.Cookies())
+		}
+	}
+	ws.MaxPayloadBytes = int(h.cfg.MaxResponseSize)
+	return ws, nil
+}
+
+// dialWebSocketConn opens the connection to the WebSocket server at u, given
+// as its HTTP equivalent, through the proxy of opts if one applies, and
+// secures it for https with the certificates of opts
+func (h *ExecutionHandler) dialWebSocketConn(ctx context.Context, u *url.URL, opts requestOptions) (net.Conn, error) {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: h.cfg.RequestTimeout}
+	proxyURL, err := opts.proxy.proxyFor(u)
+	if err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	if proxyURL != nil {
+		conn, err = dialProxy(ctx, dialer, proxyURL, address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return conn, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if !opts.tls.isDefault() {
+		tlsConfig = opts.tls.configFor(u)
+	}
+	tlsConfig.ServerName = u.Hostname()
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshakeRecorder keeps what is read from a connection up to the end of
+// the headers of the handshake response, which websocket.NewClient does not
+// expose
+type handshakeRecorder struct {
+	net.Conn
+	head []byte
+	done bool
+}
+
+// maxHandshakeResponseSize bounds the handshake response headers kept
+const maxHandshakeResponseSize = 64 << 10
+
+func (r *handshakeRecorder) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	if !r.done {
+		r.head = append(r.head, p[:n]...)
+		if i := bytes.Index(r.head, []byte("\r\n\r\n")); i >= 0 {
+			r.head, r.done = r.head[:i+4], true
+		} else if len(r.head) > maxHandshakeResponseSize {
+			r.head, r.done = nil, true
+		}
+	}
+	return n, err
+}
+
+// response parses the recorded handshake response, or returns nil if its
+// headers were not read in full
+func (r *handshakeRecorder) response() *http.Response {
+	if !r.done || r.head == nil {
+		return nil
+	}
+	response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(r.head)), nil)
+	if err != nil {
+		return nil
+	}
+	return response
+}
+
+// relayWebSocket sends the saved messages upstream, then relays frames
+// between client and upstream until the connection ends
+func (h *ExecutionHandler) relayWebSocket(ctx context.Context, client, upstream *websocket.Conn, outgoing []websocketFrame, session *websocketSession) {
+	var once sync.Once
+	end := func(reason string, err error) {
+		session.end(reason, err)
+		once.Do(func() {
+			client.Close()
+			upstream.Close()
+		})
+	}
+
+	stopCancel := context.AfterFunc(ctx, func() { end(cancelledError(ctx).Message, nil) })
+	defer stopCancel()
+
+	timer := time.AfterFunc(h.cfg.MaxStreamDuration, func() {
+		end(fmt.Sprintf("Connection exceeded the maximum duration of %s", h.cfg.MaxStreamDuration), nil)
+	})
+	defer timer.Stop()
+
+	for i := range outgoing {
+		if err := frameCodec.Send(upstream, &outgoing[i]); err != nil {
+			end("Failed to send saved message", err)
+			return
+		}
+		session.frame(models.WebSocketSent, &outgoing[i])
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		relayFrames(upstream, client, models.WebSocketReceived, session, end)
+	}()
+	go func() {
+		defer wg.Done()
+		relayFrames(client, upstream, models.WebSocketSent, session, end)
+	}()
+	wg.Wait()
+}
+
+// relayFrames copies frames from one side to the other, logging them under
+// direction, until either side fails. Frames over the size limit are dropped.
+func relayFrames(from, to *websocket.Conn, direction string, session *websocketSession, end func(string, error)) {
+	closedBy := "Closed by the caller"
+	if direction == models.WebSocketReceived {
+		closedBy = "Closed by upstream"
+	}
+
+	for {
+		var frame websocketFrame
+		err := frameCodec.Receive(from, &frame)
+		if err == websocket.ErrFrameTooLarge {
+			session.event(models.WebSocketError, fmt.Sprintf("Dropped a %s frame over the maximum size", direction))
+			continue
+		}
+		if err != nil {
+			// Only upstream failures are errors of the connection
+			if errors.Is(err, io.EOF) || direction == models.WebSocketSent {
+				err = nil
+			}
+			end(closedBy, err)
+			return
+		}
+
+		session.frame(direction, &frame)
+		if err := frameCodec.Send(to, &frame); err != nil {
+			if direction == models.WebSocketReceived {
+				end("Closed by the caller", nil)
+			} else {
+				end("Failed to send to upstream", err)
+			}
+			return
+		}
+	}
+}
+
+// websocketSession collects the log of a proxied connection. Frame data is
+// kept up to budget bytes in total.
+type websocketSession struct {
+	mu            sync.Mutex
+	log           []models.WebSocketLogEntry
+	frames        int
+	dropped       int
+	budget        int64
+	receivedBytes int64
+	reason        string // Why the connection ended, set once
+	err           error
+}
+
+func newWebSocketSession(budget int64) *websocketSession {
+	return &websocketSession{budget: budget}
+}
+
+// frame logs a relayed frame
+func (s *websocketSession) frame(direction string, frame *websocketFrame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if direction == models.WebSocketReceived {
+		s.receivedBytes += int64(len(frame.data))
+	}
+	if s.frames >= maxWebSocketLogEntries {
+		s.dropped++
+		return
+	}
+	s.frames++
+
+	entry := models.WebSocketLogEntry{
+		Time:      time.Now(),
+		Direction: direction,
+		Type:      models.WebSocketText,
+		SizeBytes: len(frame.data),
+	}
+	if frame.payloadType == websocket.BinaryFrame {
+		data := frame.data
+		if int64(len(data)) > s.budget {
+			data = data[:s.budget]
+			entry.Truncated = true
+		}
+		entry.Type = models.WebSocketBinary
+		entry.Data = base64.StdEncoding.EncodeToString(data)
+		entry.Encoding = models.BodyEncodingBase64
+		s.budget -= int64(len(data))
+	} else {
+		entry.Data, entry.Truncated = truncateForStorage(string(frame.data), s.budget)
+		// Invalid UTF-8 is replaced by longer sequences, which may overrun the budget
+		s.budget = max(0, s.budget-int64(len(entry.Data)))
+	}
+	s.log = append(s.log, entry)
+}
+
+// event logs a connection event
+func (s *websocketSession) event(eventType, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addEvent(eventType, message)
+}
+
+func (s *websocketSession) addEvent(eventType, message string) {
+	s.log = append(s.log, models.WebSocketLogEntry{
+		Time:      time.Now(),
+		Direction: models.WebSocketSystem,
+		Type:      eventType,
+		Data:      message,
+	})
+}
+
+// end records why the connection ended, keeping the first reason given
+func (s *websocketSession) end(reason string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason == "" {
+		s.reason, s.err = reason, err
+	}
+}
+
+// finish closes the log with the reason the connection ended and returns it
+func (s *websocketSession) finish() []models.WebSocketLogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message := s.reason
+	if s.err != nil {
+		message += ": " + s.err.Error()
+	}
+	if s.dropped > 0 {
+		message += fmt.Sprintf(" (%d more frames not logged)", s.dropped)
+	}
+	s.addEvent(models.WebSocketClose, message)
+	return s.log
+}
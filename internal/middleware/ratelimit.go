@@ -8,6 +8,10 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// RateLimiterKey is the context key under which RateLimitMiddleware stores
+// the caller's limiter, for handlers that send several requests on their behalf
+const RateLimiterKey = "rate_limiter"
+
 type IPRateLimiter struct {
 	ips map[string]*rate.Limiter
 	mu  *sync.RWMutex
@@ -51,6 +55,7 @@ func RateLimitMiddleware(limiter *IPRateLimiter) gin.HandlerFunc {
 			return
 		}
 
+		c.Set(RateLimiterKey, l)
 		c.Next()
 	}
 }
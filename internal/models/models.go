@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -9,30 +10,38 @@ type Collection struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
+	Auth        *Auth     `json:"auth,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type CollectionItem struct {
-	ID              int              `json:"id"`
-	CollectionID    int              `json:"collection_id"`
-	ParentID        sql.NullInt64    `json:"parent_id,omitempty"`
-	Name            string           `json:"name"`
-	ItemType        string           `json:"item_type"` // "folder" or "request"
-	SortOrder       int              `json:"sort_order"`
-	Method          sql.NullString   `json:"method,omitempty"`
-	URL             sql.NullString   `json:"url,omitempty"`
-	Headers         sql.NullString   `json:"headers,omitempty"` // JSONB as string
-	Body            sql.NullString   `json:"body,omitempty"`
-	ExtractionRules []ExtractionRule `json:"extraction_rules,omitempty"`
-	CreatedAt       time.Time        `json:"created_at"`
-	UpdatedAt       time.Time        `json:"updated_at"`
+	ID              int                `json:"id"`
+	CollectionID    int                `json:"collection_id"`
+	ParentID        sql.NullInt64      `json:"parent_id,omitempty"`
+	Name            string             `json:"name"`
+	ItemType        string             `json:"item_type"` // "folder", "request" or "websocket"
+	SortOrder       int                `json:"sort_order"`
+	Method          sql.NullString     `json:"method,omitempty"`
+	URL             sql.NullString     `json:"url,omitempty"`
+	Headers         sql.NullString     `json:"headers,omitempty"` // JSONB as string
+	Body            sql.NullString     `json:"body,omitempty"`
+	BodyMode        string             `json:"body_mode,omitempty"`
+	BodyData        *BodyData          `json:"body_data,omitempty"`
+	ExtractionRules []ExtractionRule   `json:"extraction_rules,omitempty"`
+	Assertions      []Assertion        `json:"assertions,omitempty"`
+	Auth            *Auth              `json:"auth,omitempty"`
+	RetryPolicy     *RetryPolicy       `json:"retry_policy,omitempty"`
+	Messages        []WebSocketMessage `json:"messages,omitempty"` // Saved messages of websocket items
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
 }
 
 // Postman Collection Schema (simplified)
 type PostmanCollection struct {
 	Info PostmanInfo   `json:"info"`
 	Item []PostmanItem `json:"item"`
+	Auth PostmanAuth   `json:"auth,omitempty"`
 }
 
 type PostmanInfo struct {
@@ -45,6 +54,7 @@ type PostmanItem struct {
 	Name    string          `json:"name"`
 	Item    []PostmanItem   `json:"item,omitempty"`    // For folders
 	Request *PostmanRequest `json:"request,omitempty"` // For requests
+	Auth    PostmanAuth     `json:"auth,omitempty"`    // For folders
 }
 
 type PostmanRequest struct {
@@ -52,6 +62,7 @@ type PostmanRequest struct {
 	Header []PostmanHeader `json:"header,omitempty"`
 	Body   *PostmanBody    `json:"body,omitempty"`
 	URL    interface{}     `json:"url"` // Can be string or object
+	Auth   PostmanAuth     `json:"auth,omitempty"`
 }
 
 type PostmanHeader struct {
@@ -59,9 +70,83 @@ type PostmanHeader struct {
 	Value string `json:"value"`
 }
 
+// PostmanAuth is a Postman auth object, e.g. {"type": "bearer", "bearer": [...]}.
+// The attributes of the chosen type are listed under a key named after it,
+// as key/value pairs (v2.1) or as a plain object (v2.0).
+type PostmanAuth map[string]interface{}
+
+// Auth types, named as in Postman
+const (
+	AuthTypeNoAuth  = "noauth"
+	AuthTypeInherit = "inherit"
+	AuthTypeBearer  = "bearer"
+	AuthTypeBasic   = "basic"
+	AuthTypeAPIKey  = "apikey"
+	AuthTypeDigest  = "digest"
+	AuthTypeOAuth2  = "oauth2"
+	AuthTypeAWSV4   = "awsv4"
+	AuthTypeHMAC    = "hmac"
+)
+
+// Auth is the authentication configured on a request, folder or collection.
+// Params holds the attributes of Type under their Postman names.
+type Auth struct {
+	Type   string            `json:"type"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Postman body modes
+const (
+	BodyModeRaw        = "raw"
+	BodyModeURLEncoded = "urlencoded"
+	BodyModeFormData   = "formdata"
+	BodyModeFile       = "file"
+	BodyModeGraphQL    = "graphql"
+)
+
 type PostmanBody struct {
-	Mode string `json:"mode"`
-	Raw  string `json:"raw,omitempty"`
+	Mode       string          `json:"mode"`
+	Raw        string          `json:"raw,omitempty"`
+	URLEncoded []PostmanParam  `json:"urlencoded,omitempty"`
+	FormData   []PostmanParam  `json:"formdata,omitempty"`
+	File       *PostmanFile    `json:"file,omitempty"`
+	GraphQL    *PostmanGraphQL `json:"graphql,omitempty"`
+}
+
+// PostmanParam is a urlencoded or form-data field. Form-data file parts have
+// type "file" and reference the file through Src; since Postman only stores a
+// local path, the file itself is attached through Content.
+type PostmanParam struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value,omitempty"`
+	Type        string      `json:"type,omitempty"` // "text" (default) or "file"
+	Src         interface{} `json:"src,omitempty"`  // Can be string or array of strings
+	ContentType string      `json:"contentType,omitempty"`
+	Disabled    bool        `json:"disabled,omitempty"`
+	Content     string      `json:"content,omitempty"` // Base64-encoded file content
+}
+
+// PostmanFile is the body of a binary (file mode) request
+type PostmanFile struct {
+	Src     string `json:"src,omitempty"`
+	Content string `json:"content,omitempty"` // Base64-encoded file content
+}
+
+// PostmanGraphQL is the body of a GraphQL request. Postman stores the
+// variables as a JSON string. OperationName selects the operation to run
+// when the query defines several.
+type PostmanGraphQL struct {
+	Query         string `json:"query"`
+	Variables     string `json:"variables,omitempty"`
+	OperationName string `json:"operationName,omitempty"`
+}
+
+// BodyData holds the structured body of every non-raw body mode
+type BodyData struct {
+	URLEncoded []PostmanParam  `json:"urlencoded,omitempty"`
+	FormData   []PostmanParam  `json:"formdata,omitempty"`
+	File       *PostmanFile    `json:"file,omitempty"`
+	GraphQL    *PostmanGraphQL `json:"graphql,omitempty"`
 }
 
 // Response structures
@@ -70,11 +155,259 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// UnresolvedVariablesResponse is returned when a request references
+// {{variables}} that are not defined in the selected environment
+type UnresolvedVariablesResponse struct {
+	Error     string   `json:"error"`
+	Message   string   `json:"message,omitempty"`
+	Variables []string `json:"variables"`
+}
+
+// BodyEncodingBase64 marks a body that was base64-encoded because it is not text
+const BodyEncodingBase64 = "base64"
+
 type ExecutionResponse struct {
-	Status     int               `json:"status"`
-	Headers    map[string]string `json:"headers"`
-	Body       string            `json:"body"`
-	DurationMs int64             `json:"duration_ms"`
+	ExecutionID        int                 `json:"execution_id,omitempty"`
+	Status             int                 `json:"status"`
+	Headers            map[string][]string `json:"headers"`
+	Body               string              `json:"body"`
+	BodyEncoding       string              `json:"body_encoding,omitempty"` // "base64" for binary bodies
+	SizeBytes          int64               `json:"size_bytes"`
+	Truncated          bool                `json:"truncated"`
+	DurationMs         int64               `json:"duration_ms"`
+	ExtractedVariables map[string]string   `json:"extracted_variables,omitempty"`
+	ExtractionErrors   map[string]string   `json:"extraction_errors,omitempty"` // variable_name -> reason
+	Assertions         []AssertionResult   `json:"assertions,omitempty"`
+	Timing             *TimingInfo         `json:"timing,omitempty"`
+	TLS                *TLSInfo            `json:"tls,omitempty"`
+	Attempts           []ExecutionAttempt  `json:"attempts,omitempty"` // Set when a retry policy applies
+}
+
+// Network error classes a retry policy can retry on
+const (
+	RetryOnTimeout    = "timeout"    // Connect, TLS handshake or response timeouts
+	RetryOnConnection = "connection" // Refused, reset or prematurely closed connections
+	RetryOnDNS        = "dns"        // Host name resolution failures
+)
+
+// RetryPolicy controls how a request is retried. A nil status or error list
+// selects the defaults: statuses 429, 502, 503 and 504, and timeout and
+// connection errors. An empty list disables that kind of retry.
+type RetryPolicy struct {
+	MaxAttempts    int      `json:"max_attempts"` // Including the first; 0 or 1 disables retries
+	RetryOnStatus  []int    `json:"retry_on_status"`
+	RetryOnErrors  []string `json:"retry_on_errors"`
+	InitialDelayMs int      `json:"initial_delay_ms,omitempty"` // Backoff before the first retry, doubled for each one after
+	MaxDelayMs     int      `json:"max_delay_ms,omitempty"`     // Cap on the backoff and on honoured Retry-After delays
+}
+
+// ExecutionAttempt is one try of a request sent under a retry policy
+type ExecutionAttempt struct {
+	Attempt    int    `json:"attempt"`
+	Status     *int   `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	DelayMs    int64  `json:"delay_ms,omitempty"` // Wait before the next attempt
+}
+
+// WebSocket message types
+const (
+	WebSocketText   = "text"
+	WebSocketBinary = "binary"
+)
+
+// WebSocketMessage is a message saved on a websocket item, ready to be sent
+// once connected. Binary data is base64-encoded; text data may reference
+// {{variables}}.
+type WebSocketMessage struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // "text" (default) or "binary"
+	Data string `json:"data"`
+}
+
+// Directions and events of a websocket connection log
+const (
+	WebSocketSent     = "sent"     // A frame sent upstream
+	WebSocketReceived = "received" // A frame received from upstream
+	WebSocketSystem   = "system"   // A connection event
+
+	WebSocketOpen  = "open"
+	WebSocketClose = "close"
+	WebSocketError = "error"
+)
+
+// WebSocketLogEntry is one frame or event of a proxied websocket connection.
+// Frame data beyond the history size limit is left out.
+type WebSocketLogEntry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // "sent", "received" or "system"
+	Type      string    `json:"type"`      // "text" or "binary" for frames; "open", "close" or "error" for events
+	Data      string    `json:"data,omitempty"`
+	Encoding  string    `json:"encoding,omitempty"` // "base64" for binary frames
+	SizeBytes int       `json:"size_bytes,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// Reasons a streamed execution ended
+const (
+	StreamCompleted          = "completed"           // The upstream body ended
+	StreamStopped            = "stopped"             // Cancelled through DELETE /executions/:id
+	StreamTimeout            = "timeout"             // No response headers in time, or the stream outlived its maximum duration
+	StreamClientDisconnected = "client_disconnected" // The caller went away
+	StreamMaxSize            = "max_size"            // A single upstream event exceeded the response size limit
+	StreamMaxEvents          = "max_events"          // The stream relayed its maximum number of events
+	StreamError              = "error"               // Reading the upstream body failed
+)
+
+// StreamStart is the first event of a streamed execution, sent once the
+// upstream response headers arrive
+type StreamStart struct {
+	ExecutionID int                 `json:"execution_id,omitempty"` // Under which DELETE /executions/:id stops the stream
+	Status      int                 `json:"status"`
+	Headers     map[string][]string `json:"headers"`
+}
+
+// StreamChunk is a piece of a non-SSE upstream body, relayed as it arrives
+type StreamChunk struct {
+	Data     string `json:"data"`
+	Encoding string `json:"encoding,omitempty"` // "base64" for binary bodies
+}
+
+// StreamEvent is a Server-Sent Event parsed from an upstream event stream
+type StreamEvent struct {
+	Event string `json:"event,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Data  string `json:"data"`
+	Retry *int   `json:"retry,omitempty"`
+}
+
+// StreamSummary is the last event of a streamed execution
+type StreamSummary struct {
+	ExecutionID        int               `json:"execution_id,omitempty"`
+	Status             int               `json:"status"`
+	Reason             string            `json:"reason"`
+	Error              string            `json:"error,omitempty"`
+	Events             int               `json:"events"` // Chunks or events relayed
+	SizeBytes          int64             `json:"size_bytes"`
+	Truncated          bool              `json:"truncated"`
+	DurationMs         int64             `json:"duration_ms"`
+	Timing             *TimingInfo       `json:"timing,omitempty"`
+	Assertions         []AssertionResult `json:"assertions,omitempty"`
+	ExtractedVariables map[string]string `json:"extracted_variables,omitempty"`
+	ExtractionErrors   map[string]string `json:"extraction_errors,omitempty"`
+}
+
+// Reasons a load test ended
+const (
+	LoadTestCompleted = "completed" // Every iteration ran, or the duration elapsed
+	LoadTestTimeout   = "timeout"   // The maximum load test duration elapsed before every iteration ran
+)
+
+// LoadTestStart is the first event of a load test
+type LoadTestStart struct {
+	ItemID      int                `json:"item_id"`
+	Concurrency int                `json:"concurrency"`
+	Iterations  int                `json:"iterations,omitempty"`
+	DurationMs  int64              `json:"duration_ms,omitempty"`
+	RampUpMs    int64              `json:"ramp_up_ms,omitempty"`
+	Requests    []LoadTestItemInfo `json:"requests"`
+}
+
+// LoadTestItemInfo identifies a request hammered by a load test
+type LoadTestItemInfo struct {
+	ItemID int    `json:"item_id"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// LoadTestStats aggregates the responses to load test requests. Requests
+// that fail to complete count as errors; completed requests whose status or
+// assertions fail count as failures.
+type LoadTestStats struct {
+	Requests      int64            `json:"requests"`
+	Errors        int64            `json:"errors"`
+	Failures      int64            `json:"failures"`
+	ErrorRate     float64          `json:"error_rate"` // Errors and failures over requests
+	ThroughputRPS float64          `json:"throughput_rps"`
+	StatusCodes   map[int]int64    `json:"status_codes"`
+	ErrorMessages map[string]int64 `json:"error_messages,omitempty"`
+	Latency       LoadTestLatency  `json:"latency_ms"`
+}
+
+// LoadTestLatency summarizes request durations in milliseconds
+type LoadTestLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	Max  float64 `json:"max"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+}
+
+// LoadTestItemStats is the share of a load test's statistics for one request
+type LoadTestItemStats struct {
+	LoadTestItemInfo
+	LoadTestStats
+}
+
+// LoadTestProgress is sent periodically while a load test runs
+type LoadTestProgress struct {
+	ElapsedMs           int64 `json:"elapsed_ms"`
+	ActiveUsers         int   `json:"active_users"`
+	CompletedIterations int64 `json:"completed_iterations"`
+	LoadTestStats
+}
+
+// LoadTestReport is the last event of a load test
+type LoadTestReport struct {
+	Reason              string `json:"reason"`
+	DurationMs          int64  `json:"duration_ms"`
+	CompletedIterations int64  `json:"completed_iterations"`
+	LoadTestStats
+	Requests []LoadTestItemStats `json:"requests"`
+}
+
+// TLSInfo describes the TLS connection a response was received over
+type TLSInfo struct {
+	Version              string            `json:"version"`
+	CipherSuite          string            `json:"cipher_suite"`
+	ServerName           string            `json:"server_name,omitempty"`
+	NegotiatedProtocol   string            `json:"negotiated_protocol,omitempty"`
+	VerificationDisabled bool              `json:"verification_disabled,omitempty"`
+	PeerCertificates     []PeerCertificate `json:"peer_certificates"`
+}
+
+// PeerCertificate is one certificate of the chain presented by the server
+type PeerCertificate struct {
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	DNSNames          []string  `json:"dns_names,omitempty"`
+	SHA256Fingerprint string    `json:"sha256_fingerprint"`
+}
+
+// TimingInfo is the phase breakdown of a request. It uses the same shape as
+// the agent's TimingInfo so both can be rendered as one waterfall. DNSLookup,
+// TCPConnection and TLSHandshake are whole milliseconds; the ...Ms fields and
+// the other phases are fractional milliseconds. Phases skipped, e.g. DNS, TCP
+// and TLS on a reused connection, are 0.
+type TimingInfo struct {
+	StartTime        time.Time `json:"startTime"`
+	EndTime          time.Time `json:"endTime"`
+	Duration         int64     `json:"duration"` // milliseconds
+	DNSLookup        int64     `json:"dnsLookup,omitempty"`
+	TCPConnection    int64     `json:"tcpConnection,omitempty"`
+	TLSHandshake     int64     `json:"tlsHandshake,omitempty"`
+	DNSLookupMs      float64   `json:"dnsLookupMs"`
+	TCPConnectionMs  float64   `json:"tcpConnectionMs"`
+	TLSHandshakeMs   float64   `json:"tlsHandshakeMs"`
+	TimeToFirstByte  float64   `json:"timeToFirstByte"`
+	ContentDownload  float64   `json:"contentDownload"`
+	ConnectionReused bool      `json:"connectionReused"`
 }
 
 // Tree structure for collection retrieval
@@ -84,16 +417,43 @@ type CollectionTree struct {
 }
 
 type ItemTreeNode struct {
-	ID              int              `json:"id"`
-	Name            string           `json:"name"`
-	ItemType        string           `json:"item_type"`
-	SortOrder       int              `json:"sort_order"`
-	Method          string           `json:"method,omitempty"`
-	URL             string           `json:"url,omitempty"`
-	Headers         string           `json:"headers,omitempty"`
-	Body            string           `json:"body,omitempty"`
-	ExtractionRules []ExtractionRule `json:"extraction_rules,omitempty"`
-	Children        []ItemTreeNode   `json:"children,omitempty"`
+	ID              int                `json:"id"`
+	Name            string             `json:"name"`
+	ItemType        string             `json:"item_type"`
+	SortOrder       int                `json:"sort_order"`
+	Method          string             `json:"method,omitempty"`
+	URL             string             `json:"url,omitempty"`
+	Headers         string             `json:"headers,omitempty"`
+	Body            string             `json:"body,omitempty"`
+	BodyMode        string             `json:"body_mode,omitempty"`
+	BodyData        *BodyData          `json:"body_data,omitempty"`
+	ExtractionRules []ExtractionRule   `json:"extraction_rules,omitempty"`
+	Assertions      []Assertion        `json:"assertions,omitempty"`
+	Auth            *Auth              `json:"auth,omitempty"`
+	RetryPolicy     *RetryPolicy       `json:"retry_policy,omitempty"`
+	Messages        []WebSocketMessage `json:"messages,omitempty"`
+	Children        []ItemTreeNode     `json:"children,omitempty"`
+}
+
+// GraphQLSchema is the schema introspected from a GraphQL endpoint, cached
+// per collection and endpoint URL
+type GraphQLSchema struct {
+	CollectionID int             `json:"collection_id"`
+	URL          string          `json:"url"`
+	Schema       json.RawMessage `json:"schema"` // The __schema object of the introspection result
+	FetchedAt    time.Time       `json:"fetched_at"`
+}
+
+// GraphQLError is a problem found when validating a query against a schema
+type GraphQLError struct {
+	Message   string            `json:"message"`
+	Locations []GraphQLLocation `json:"locations,omitempty"`
+}
+
+// GraphQLLocation is a position in a GraphQL query, counted from 1
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 // Environment represents a set of variables for request execution
@@ -113,9 +473,288 @@ type EnvironmentVariable struct {
 	Value string `json:"value"`
 }
 
+// Certificate holds TLS material for the hosts matching HostPattern, e.g.
+// "api.internal.example.com", "*.internal.example.com" or "host:8443".
+// Certificates without an environment apply to every execution. The private
+// key is stored, encrypted when CERTIFICATE_ENCRYPTION_KEY is set, but never
+// returned.
+type Certificate struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	EnvironmentID *int      `json:"environment_id,omitempty"`
+	HostPattern   string    `json:"host_pattern"`
+	ClientCert    string    `json:"client_cert,omitempty"` // PEM
+	ClientKey     string    `json:"-"`                     // PEM
+	HasClientKey  bool      `json:"has_client_key"`
+	CACert        string    `json:"ca_cert,omitempty"` // PEM bundle of extra trusted CAs
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TLSAuditEntry records a change to an environment's certificate verification
+// setting. Entries outlive the environment, which is why its name is kept.
+type TLSAuditEntry struct {
+	ID              int       `json:"id"`
+	EnvironmentID   int       `json:"environment_id"`
+	EnvironmentName string    `json:"environment_name"`
+	SkipVerify      bool      `json:"skip_verify"`
+	ChangedBy       string    `json:"changed_by,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Cookie is a cookie stored in an environment's cookie jar. Host-only cookies
+// are sent to Domain exactly, others to its subdomains as well. A nil Expires
+// marks a session cookie, which is kept until it is cleared.
+type Cookie struct {
+	ID            int        `json:"id"`
+	EnvironmentID int        `json:"environment_id"`
+	Name          string     `json:"name"`
+	Value         string     `json:"value"`
+	Domain        string     `json:"domain"`
+	Path          string     `json:"path"`
+	Expires       *time.Time `json:"expires,omitempty"`
+	Secure        bool       `json:"secure"`
+	HTTPOnly      bool       `json:"http_only"`
+	HostOnly      bool       `json:"host_only"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
 // ExtractionRule represents a rule for extracting values from API responses
 type ExtractionRule struct {
 	Enabled      bool   `json:"enabled"`
 	JSONPath     string `json:"json_path"`
 	VariableName string `json:"variable_name"`
 }
+
+// Assertion types supported on request items
+const (
+	AssertStatusEquals      = "status_equals"       // Value: status code
+	AssertStatusInRange     = "status_in_range"     // Min, Max: inclusive bounds
+	AssertHeaderExists      = "header_exists"       // Header
+	AssertHeaderEquals      = "header_equals"       // Header, Value
+	AssertJSONPathEquals    = "json_path_equals"    // JSONPath, Value (any JSON value)
+	AssertJSONPathContains  = "json_path_contains"  // JSONPath, Value (substring or array element)
+	AssertJSONPathMatches   = "json_path_matches"   // JSONPath, Value (regular expression)
+	AssertJSONPathType      = "json_path_type"      // JSONPath, Value: string, number, boolean, object, array or null
+	AssertBodyContains      = "body_contains"       // Value: substring
+	AssertResponseTimeBelow = "response_time_below" // Value: milliseconds
+)
+
+// Assertion is a declarative check evaluated against every execution of an item
+type Assertion struct {
+	Enabled  bool        `json:"enabled"`
+	Type     string      `json:"type"`
+	Header   string      `json:"header,omitempty"`
+	JSONPath string      `json:"json_path,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	Min      *int        `json:"min,omitempty"`
+	Max      *int        `json:"max,omitempty"`
+}
+
+// AssertionResult is the outcome of evaluating a single assertion
+type AssertionResult struct {
+	Assertion
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// Run is a persisted report of executing every request in a collection or folder
+type Run struct {
+	ID             int         `json:"id"`
+	CollectionID   int         `json:"collection_id"`
+	FolderID       *int        `json:"folder_id,omitempty"`
+	EnvironmentID  *int        `json:"environment_id,omitempty"`
+	MonitorID      *int        `json:"monitor_id,omitempty"` // Set for runs started by a monitor
+	Status         string      `json:"status"`               // "running", "passed", "failed" or "cancelled"
+	TotalRequests  int         `json:"total_requests"`
+	PassedRequests int         `json:"passed_requests"`
+	FailedRequests int         `json:"failed_requests"`
+	DurationMs     int64       `json:"duration_ms"`
+	StartedAt      time.Time   `json:"started_at"`
+	FinishedAt     *time.Time  `json:"finished_at,omitempty"`
+	Results        []RunResult `json:"results"` // Empty for data-driven runs, whose results are grouped in Iterations
+
+	Iterations []RunIteration `json:"iterations,omitempty"`
+}
+
+// RunIteration groups the results of one pass over a run's requests with a
+// row of iteration data
+type RunIteration struct {
+	Iteration      int               `json:"iteration"` // Starting at 1
+	Data           map[string]string `json:"data"`
+	Passed         bool              `json:"passed"`
+	PassedRequests int               `json:"passed_requests"`
+	FailedRequests int               `json:"failed_requests"`
+	Results        []RunResult       `json:"results"`
+}
+
+// Monitor runs a collection, or a folder of it, on a cron schedule. Times
+// are reported in UTC; the schedule is evaluated in the monitor's timezone.
+type Monitor struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	CollectionID   int        `json:"collection_id"`
+	FolderID       *int       `json:"folder_id,omitempty"`
+	EnvironmentID  *int       `json:"environment_id,omitempty"`
+	CronExpression string     `json:"cron_expression"`
+	Timezone       string     `json:"timezone"`
+	Enabled        bool       `json:"enabled"`
+	StopOnFailure  bool       `json:"stop_on_failure"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"` // Why the last run could not be started
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MonitorRun summarizes a run started by a monitor; GET /runs/:id has its results
+type MonitorRun struct {
+	RunID          int        `json:"run_id"`
+	Status         string     `json:"status"`
+	TotalRequests  int        `json:"total_requests"`
+	PassedRequests int        `json:"passed_requests"`
+	FailedRequests int        `json:"failed_requests"`
+	DurationMs     int64      `json:"duration_ms"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// MonitorResults lists the latest runs of a monitor
+type MonitorResults struct {
+	MonitorID     int          `json:"monitor_id"`
+	Runs          []MonitorRun `json:"runs"`           // Most recent first
+	UptimePercent *float64     `json:"uptime_percent"` // Share of the finished runs listed that passed
+}
+
+// RunResult is the outcome of a single request within a run
+type RunResult struct {
+	ID          int    `json:"id"`
+	ItemID      *int   `json:"item_id,omitempty"`
+	Sequence    int    `json:"sequence"`
+	Iteration   int    `json:"iteration,omitempty"` // Set for data-driven runs
+	ItemName    string `json:"item_name"`
+	Method      string `json:"method,omitempty"`
+	URL         string `json:"url,omitempty"`
+	StatusCode  *int   `json:"status_code,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	Passed      bool   `json:"passed"`
+	Error       string `json:"error,omitempty"`
+	Cancelled   bool   `json:"cancelled,omitempty"` // The run was cancelled while this request was in flight
+	ExecutionID *int   `json:"execution_id,omitempty"`
+
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+}
+
+// Execution is a stored record of a single request execution
+type Execution struct {
+	ID                   int                 `json:"id"`
+	ItemID               *int                `json:"item_id,omitempty"`
+	EnvironmentID        *int                `json:"environment_id,omitempty"`
+	Method               string              `json:"method"`
+	URL                  string              `json:"url"`
+	RequestHeaders       map[string]string   `json:"request_headers"`
+	RequestBody          string              `json:"request_body,omitempty"`
+	RequestBodyEncoding  string              `json:"request_body_encoding,omitempty"`
+	ResponseStatus       *int                `json:"response_status,omitempty"`
+	ResponseHeaders      map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody         string              `json:"response_body,omitempty"`
+	ResponseBodyEncoding string              `json:"response_body_encoding,omitempty"`
+	ResponseSizeBytes    int64               `json:"response_size_bytes"`
+	ResponseTruncated    bool                `json:"response_truncated"`
+	DurationMs           int64               `json:"duration_ms"`
+	Attempts             []ExecutionAttempt  `json:"attempts,omitempty"`
+	WebSocketLog         []WebSocketLogEntry `json:"websocket_log,omitempty"` // Frames and events of websocket connections
+	Error                string              `json:"error,omitempty"`
+	Cancelled            bool                `json:"cancelled,omitempty"` // Cancelled before the response was received
+	RunID                *int                `json:"run_id,omitempty"`    // Set for executions of a collection run
+	CreatedAt            time.Time           `json:"created_at"`
+	FinishedAt           *time.Time          `json:"finished_at,omitempty"` // Unset while the execution is in progress
+}
+
+// Body diff modes
+const (
+	DiffModeJSON   = "json"   // Both bodies are JSON, compared structurally
+	DiffModeText   = "text"   // Compared line by line
+	DiffModeBinary = "binary" // At least one body is binary; only equality is reported
+)
+
+// Diff change types
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// ExecutionDiff compares the responses of two stored executions, from A to B
+type ExecutionDiff struct {
+	A         int        `json:"a"`
+	B         int        `json:"b"`
+	Identical bool       `json:"identical"` // No differences outside the ignored paths and headers
+	Status    StatusDiff `json:"status"`
+	Headers   HeaderDiff `json:"headers"`
+	Body      BodyDiff   `json:"body"`
+}
+
+// StatusDiff compares response statuses. A status is null when the
+// execution got no response.
+type StatusDiff struct {
+	A       *int `json:"a"`
+	B       *int `json:"b"`
+	Changed bool `json:"changed"`
+}
+
+// HeaderDiff lists the response headers that differ, by canonical name
+type HeaderDiff struct {
+	Added   map[string][]string     `json:"added"`
+	Removed map[string][]string     `json:"removed"`
+	Changed map[string]HeaderChange `json:"changed"`
+}
+
+// HeaderChange holds the values of a header present in both responses
+type HeaderChange struct {
+	A []string `json:"a"`
+	B []string `json:"b"`
+}
+
+// BodyDiff compares response bodies. JSON bodies list changes by path, text
+// bodies the lines added and removed.
+type BodyDiff struct {
+	Mode       string       `json:"mode"`
+	Equal      bool         `json:"equal"`
+	Changes    []JSONChange `json:"changes,omitempty"`
+	Lines      []LineChange `json:"lines,omitempty"`
+	Truncated  bool         `json:"truncated,omitempty"`  // Only the first changes are listed
+	Incomplete bool         `json:"incomplete,omitempty"` // A stored body was truncated, so the comparison covers a prefix
+}
+
+// JSONChange is a value added, removed or changed at a JSONPath
+type JSONChange struct {
+	Path string          `json:"path"`
+	Type string          `json:"type"`
+	A    json.RawMessage `json:"a,omitempty"` // Absent for added values
+	B    json.RawMessage `json:"b,omitempty"` // Absent for removed values
+}
+
+// LineChange is a line only one of the bodies has. Line numbers start at 1.
+type LineChange struct {
+	Type  string `json:"type"` // "added" or "removed"
+	LineA int    `json:"line_a,omitempty"`
+	LineB int    `json:"line_b,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Snippet is a request item rendered as code in one of the supported languages
+type Snippet struct {
+	ItemID              int      `json:"item_id"`
+	Language            string   `json:"lang"`
+	Snippet             string   `json:"snippet"`
+	UnresolvedVariables []string `json:"unresolved_variables,omitempty"` // Placeholders left as {{name}} in the snippet
+}
+
+// CurlImport is a request item created from a curl command line
+type CurlImport struct {
+	Item             *CollectionItem `json:"item"`
+	UnsupportedFlags []string        `json:"unsupported_flags,omitempty"` // Flags of the command the item does not reproduce
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxMethodLength matches the width of the method columns
+const MaxMethodLength = 32
+
+// methodAllowsBody lists well-known HTTP methods and whether a stored body is
+// sent with them. Methods not listed here are treated as custom verbs, which
+// are accepted as long as they are valid tokens and always send their body.
+var methodAllowsBody = map[string]bool{
+	"GET":     false,
+	"HEAD":    false,
+	"OPTIONS": false,
+	"TRACE":   false,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+
+	// WebDAV and cache-control verbs
+	"PROPFIND":  true,
+	"PROPPATCH": true,
+	"MKCOL":     true,
+	"COPY":      false,
+	"MOVE":      false,
+	"LOCK":      true,
+	"UNLOCK":    false,
+	"REPORT":    true,
+	"SEARCH":    true,
+	"PURGE":     false,
+	"QUERY":     true,
+}
+
+// blockedMethods cannot be executed through the runner
+var blockedMethods = map[string]bool{
+	"CONNECT": true, // Opens a tunnel rather than performing a request
+}
+
+// NormalizeMethod upper-cases and trims an HTTP method
+func NormalizeMethod(method string) string {
+	return strings.ToUpper(strings.TrimSpace(method))
+}
+
+// ValidateMethod checks that a method is a well-known verb or a valid custom
+// token (RFC 9110) that the runner is able to execute
+func ValidateMethod(method string) error {
+	method = NormalizeMethod(method)
+	if method == "" {
+		return fmt.Errorf("method cannot be empty")
+	}
+	if len(method) > MaxMethodLength {
+		return fmt.Errorf("method exceeds %d characters", MaxMethodLength)
+	}
+	if blockedMethods[method] {
+		return fmt.Errorf("method %s is not supported", method)
+	}
+	for _, r := range method {
+		if !isTokenChar(r) {
+			return fmt.Errorf("method %q contains invalid character %q", method, r)
+		}
+	}
+	return nil
+}
+
+// MethodAllowsBody reports whether a request body should be sent with method
+func MethodAllowsBody(method string) bool {
+	allows, known := methodAllowsBody[NormalizeMethod(method)]
+	if !known {
+		return true
+	}
+	return allows
+}
+
+// isTokenChar reports whether r is a tchar as defined by RFC 9110
+func isTokenChar(r rune) bool {
+	switch {
+	case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		return true
+	}
+	return false
+}
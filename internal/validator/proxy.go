@@ -0,0 +1,41 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseProxyURL checks the syntax of an outbound proxy URL. HTTP, HTTPS and
+// SOCKS5 proxies are supported; credentials may be given in the user info.
+func ParseProxyURL(urlStr string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(urlStr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q: must be http, https, socks5 or socks5h", parsed.Scheme)
+	}
+
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL must contain a hostname")
+	}
+	if (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return nil, fmt.Errorf("proxy URL must not contain a path, query or fragment")
+	}
+
+	return parsed, nil
+}
+
+// ValidateProxyURL checks a proxy URL supplied by a user, applying the same
+// localhost and private IP rules as ValidateExecutionURL to the proxy host
+func ValidateProxyURL(urlStr string, allowLocalhost, allowPrivateIPs bool) error {
+	parsed, err := ParseProxyURL(urlStr)
+	if err != nil {
+		return err
+	}
+	return validateHost(parsed.Hostname(), allowLocalhost, allowPrivateIPs)
+}
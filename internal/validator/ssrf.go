@@ -26,6 +26,11 @@ func ValidateExecutionURL(urlStr string, allowLocalhost, allowPrivateIPs bool) e
 		return fmt.Errorf("URL must contain a hostname")
 	}
 
+	return validateHost(hostname, allowLocalhost, allowPrivateIPs)
+}
+
+// validateHost applies the localhost and private IP checks to a hostname
+func validateHost(hostname string, allowLocalhost, allowPrivateIPs bool) error {
 	// Block localhost and loopback addresses
 	if isLocalhost(hostname) {
 		if !allowLocalhost {
@@ -74,16 +74,8 @@ func validateItems(items []models.PostmanItem, maxHeaderCount int) error {
 
 func validateRequest(req *models.PostmanRequest, maxHeaderCount int) error {
 	// Validate HTTP method
-	allowedMethods := map[string]bool{
-		"GET":    true,
-		"POST":   true,
-		"PUT":    true,
-		"PATCH":  true,
-		"DELETE": true,
-	}
-
-	if !allowedMethods[strings.ToUpper(req.Method)] {
-		return fmt.Errorf("unsupported HTTP method: %s (allowed: GET, POST, PUT, PATCH, DELETE)", req.Method)
+	if err := ValidateMethod(req.Method); err != nil {
+		return fmt.Errorf("unsupported HTTP method: %w", err)
 	}
 
 	// Validate URL (if present)
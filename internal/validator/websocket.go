@@ -0,0 +1,35 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseWebSocketURL checks the syntax of a ws or wss URL
+func ParseWebSocketURL(urlStr string) (*url.URL, error) {
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "ws" && scheme != "wss" {
+		return nil, fmt.Errorf("unsupported WebSocket URL scheme %q: must be ws or wss", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("URL must contain a hostname")
+	}
+
+	return parsed, nil
+}
+
+// ValidateWebSocketURL performs the SSRF checks of ValidateExecutionURL on
+// the target of a WebSocket upgrade
+func ValidateWebSocketURL(urlStr string, allowLocalhost, allowPrivateIPs bool) error {
+	parsed, err := ParseWebSocketURL(urlStr)
+	if err != nil {
+		return err
+	}
+	return validateHost(parsed.Hostname(), allowLocalhost, allowPrivateIPs)
+}
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE runs (
+    id SERIAL PRIMARY KEY,
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    folder_id INTEGER REFERENCES collection_items(id) ON DELETE SET NULL,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'passed', 'failed')),
+    total_requests INTEGER NOT NULL DEFAULT 0,
+    passed_requests INTEGER NOT NULL DEFAULT 0,
+    failed_requests INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_runs_collection_id ON runs(collection_id);
+CREATE INDEX idx_runs_started_at ON runs(started_at);
+
+CREATE TABLE run_results (
+    id SERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES collection_items(id) ON DELETE SET NULL,
+    sequence INTEGER NOT NULL,
+    item_name VARCHAR(255) NOT NULL,
+    method VARCHAR(10),
+    url TEXT,
+    status_code INTEGER,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    passed BOOLEAN NOT NULL,
+    error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_run_results_run_id ON run_results(run_id, sequence);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS run_results;
+DROP TABLE IF EXISTS runs;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE executions (
+    id SERIAL PRIMARY KEY,
+    item_id INTEGER REFERENCES collection_items(id) ON DELETE CASCADE,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+
+    -- Resolved request
+    method VARCHAR(10) NOT NULL,
+    url TEXT NOT NULL,
+    request_headers JSONB NOT NULL DEFAULT '{}',
+    request_body TEXT,
+
+    -- Response (NULL when the request failed)
+    response_status INTEGER,
+    response_headers JSONB,
+    response_body TEXT,
+    response_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_executions_item_id ON executions(item_id, created_at DESC);
+
+ALTER TABLE run_results ADD COLUMN execution_id INTEGER REFERENCES executions(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE run_results DROP COLUMN IF EXISTS execution_id;
+DROP TABLE IF EXISTS executions;
+-- +goose StatementEnd
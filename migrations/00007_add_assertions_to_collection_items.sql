@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collection_items ADD COLUMN assertions JSONB DEFAULT '[]'::jsonb;
+ALTER TABLE run_results ADD COLUMN assertion_results JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE run_results DROP COLUMN IF EXISTS assertion_results;
+ALTER TABLE collection_items DROP COLUMN IF EXISTS assertions;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE executions ADD COLUMN response_body_encoding VARCHAR(20);
+ALTER TABLE executions ADD COLUMN response_size_bytes BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE executions DROP COLUMN IF EXISTS response_size_bytes;
+ALTER TABLE executions DROP COLUMN IF EXISTS response_body_encoding;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collection_items DROP CONSTRAINT IF EXISTS collection_items_method_check;
+ALTER TABLE collection_items ALTER COLUMN method TYPE VARCHAR(32);
+ALTER TABLE collection_items ADD CONSTRAINT collection_items_method_check
+    CHECK (method ~ '^[A-Z0-9!#$%&''*+.^_`|~-]+$' AND method <> 'CONNECT');
+
+ALTER TABLE executions ALTER COLUMN method TYPE VARCHAR(32);
+ALTER TABLE run_results ALTER COLUMN method TYPE VARCHAR(32);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE run_results ALTER COLUMN method TYPE VARCHAR(10);
+ALTER TABLE executions ALTER COLUMN method TYPE VARCHAR(10);
+
+ALTER TABLE collection_items DROP CONSTRAINT IF EXISTS collection_items_method_check;
+ALTER TABLE collection_items ALTER COLUMN method TYPE VARCHAR(10);
+ALTER TABLE collection_items ADD CONSTRAINT collection_items_method_check
+    CHECK (method IN ('GET', 'POST', 'PUT', 'PATCH', 'DELETE'));
+-- +goose StatementEnd
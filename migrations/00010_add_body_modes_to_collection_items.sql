@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collection_items ADD COLUMN body_mode VARCHAR(20) NOT NULL DEFAULT 'raw'
+    CHECK (body_mode IN ('raw', 'urlencoded', 'formdata', 'file', 'graphql'));
+ALTER TABLE collection_items ADD COLUMN body_data JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE collection_items DROP COLUMN IF EXISTS body_data;
+ALTER TABLE collection_items DROP COLUMN IF EXISTS body_mode;
+-- +goose StatementEnd
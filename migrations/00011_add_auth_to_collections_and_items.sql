@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collections ADD COLUMN auth JSONB;
+ALTER TABLE collection_items ADD COLUMN auth JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE collection_items DROP COLUMN IF EXISTS auth;
+ALTER TABLE collections DROP COLUMN IF EXISTS auth;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE environment_cookies (
+    id SERIAL PRIMARY KEY,
+    environment_id INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    domain VARCHAR(255) NOT NULL,
+    path VARCHAR(1024) NOT NULL DEFAULT '/',
+    expires TIMESTAMP,
+    secure BOOLEAN NOT NULL DEFAULT FALSE,
+    http_only BOOLEAN NOT NULL DEFAULT FALSE,
+    host_only BOOLEAN NOT NULL DEFAULT FALSE,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (environment_id, domain, path, name)
+);
+
+CREATE INDEX idx_environment_cookies_domain ON environment_cookies(environment_id, domain);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS environment_cookies;
+-- +goose StatementEnd
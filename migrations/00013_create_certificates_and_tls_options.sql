@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE certificates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE CASCADE,
+    host_pattern VARCHAR(255) NOT NULL,
+    client_cert TEXT,
+    client_key TEXT,
+    ca_cert TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CHECK ((client_cert IS NULL) = (client_key IS NULL)),
+    CHECK (client_cert IS NOT NULL OR ca_cert IS NOT NULL)
+);
+
+CREATE INDEX idx_certificates_environment_id ON certificates(environment_id);
+
+ALTER TABLE environments ADD COLUMN tls_skip_verify BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE environment_tls_audit (
+    id SERIAL PRIMARY KEY,
+    environment_id INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+    skip_verify BOOLEAN NOT NULL,
+    changed_by VARCHAR(255),
+    reason TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_environment_tls_audit_environment_id ON environment_tls_audit(environment_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS environment_tls_audit;
+ALTER TABLE environments DROP COLUMN IF EXISTS tls_skip_verify;
+DROP TABLE IF EXISTS certificates;
+-- +goose StatementEnd
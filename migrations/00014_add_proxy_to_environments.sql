@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE environments ADD COLUMN proxy_url TEXT;
+ALTER TABLE environments ADD COLUMN no_proxy TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE environments DROP COLUMN IF EXISTS no_proxy;
+ALTER TABLE environments DROP COLUMN IF EXISTS proxy_url;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collection_items ADD COLUMN retry_policy JSONB;
+ALTER TABLE executions ADD COLUMN attempts JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE executions DROP COLUMN IF EXISTS attempts;
+ALTER TABLE collection_items DROP COLUMN IF EXISTS retry_policy;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE collection_items DROP CONSTRAINT IF EXISTS collection_items_item_type_check;
+ALTER TABLE collection_items ADD CONSTRAINT collection_items_item_type_check
+    CHECK (item_type IN ('folder', 'request', 'websocket'));
+ALTER TABLE collection_items ADD COLUMN messages JSONB;
+
+ALTER TABLE executions ADD COLUMN websocket_log JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE executions DROP COLUMN IF EXISTS websocket_log;
+
+DELETE FROM collection_items WHERE item_type = 'websocket';
+ALTER TABLE collection_items DROP COLUMN IF EXISTS messages;
+ALTER TABLE collection_items DROP CONSTRAINT IF EXISTS collection_items_item_type_check;
+ALTER TABLE collection_items ADD CONSTRAINT collection_items_item_type_check
+    CHECK (item_type IN ('folder', 'request'));
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE graphql_schemas (
+    collection_id INTEGER PRIMARY KEY REFERENCES collections(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    schema JSONB NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS graphql_schemas;
+-- +goose StatementEnd
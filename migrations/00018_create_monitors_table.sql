@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE monitors (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    folder_id INTEGER REFERENCES collection_items(id) ON DELETE CASCADE,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE CASCADE,
+    cron_expression VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    stop_on_failure BOOLEAN NOT NULL DEFAULT FALSE,
+
+    -- Scheduling times are stored in UTC
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_monitors_next_run_at ON monitors(next_run_at) WHERE enabled;
+
+ALTER TABLE runs ADD COLUMN monitor_id INTEGER REFERENCES monitors(id) ON DELETE SET NULL;
+CREATE INDEX idx_runs_monitor_id ON runs(monitor_id, started_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE runs DROP COLUMN IF EXISTS monitor_id;
+DROP TABLE IF EXISTS monitors;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE runs ADD COLUMN iteration_data JSONB;
+ALTER TABLE run_results ADD COLUMN iteration INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE run_results DROP COLUMN IF EXISTS iteration;
+ALTER TABLE runs DROP COLUMN IF EXISTS iteration_data;
+-- +goose StatementEnd
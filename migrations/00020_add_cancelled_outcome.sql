@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE executions ADD COLUMN cancelled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE run_results ADD COLUMN cancelled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE runs DROP CONSTRAINT IF EXISTS runs_status_check;
+ALTER TABLE runs ADD CONSTRAINT runs_status_check CHECK (status IN ('running', 'passed', 'failed', 'cancelled'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE runs SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE runs DROP CONSTRAINT IF EXISTS runs_status_check;
+ALTER TABLE runs ADD CONSTRAINT runs_status_check CHECK (status IN ('running', 'passed', 'failed'));
+ALTER TABLE run_results DROP COLUMN IF EXISTS cancelled;
+ALTER TABLE executions DROP COLUMN IF EXISTS cancelled;
+-- +goose StatementEnd
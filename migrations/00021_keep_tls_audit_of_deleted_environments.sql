@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE environment_tls_audit ADD COLUMN environment_name VARCHAR(255);
+UPDATE environment_tls_audit a SET environment_name = e.name FROM environments e WHERE e.id = a.environment_id;
+ALTER TABLE environment_tls_audit ALTER COLUMN environment_name SET NOT NULL;
+
+ALTER TABLE environment_tls_audit ALTER COLUMN environment_id DROP NOT NULL;
+ALTER TABLE environment_tls_audit DROP CONSTRAINT IF EXISTS environment_tls_audit_environment_id_fkey;
+ALTER TABLE environment_tls_audit ADD CONSTRAINT environment_tls_audit_environment_id_fkey
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM environment_tls_audit WHERE environment_id IS NULL;
+ALTER TABLE environment_tls_audit DROP CONSTRAINT IF EXISTS environment_tls_audit_environment_id_fkey;
+ALTER TABLE environment_tls_audit ADD CONSTRAINT environment_tls_audit_environment_id_fkey
+    FOREIGN KEY (environment_id) REFERENCES environments(id) ON DELETE CASCADE;
+ALTER TABLE environment_tls_audit ALTER COLUMN environment_id SET NOT NULL;
+
+ALTER TABLE environment_tls_audit DROP COLUMN IF EXISTS environment_name;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE executions ADD COLUMN run_id INTEGER REFERENCES runs(id) ON DELETE SET NULL;
+ALTER TABLE executions ADD COLUMN finished_at TIMESTAMP;
+UPDATE executions SET finished_at = created_at;
+ALTER TABLE executions ADD COLUMN cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE runs ADD COLUMN cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_executions_run_id ON executions(run_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_executions_run_id;
+ALTER TABLE runs DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE executions DROP COLUMN IF EXISTS cancel_requested;
+ALTER TABLE executions DROP COLUMN IF EXISTS finished_at;
+ALTER TABLE executions DROP COLUMN IF EXISTS run_id;
+-- +goose StatementEnd
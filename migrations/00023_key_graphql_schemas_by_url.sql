@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE graphql_schemas DROP CONSTRAINT graphql_schemas_pkey;
+ALTER TABLE graphql_schemas ADD PRIMARY KEY (collection_id, url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM graphql_schemas s
+WHERE EXISTS (
+    SELECT 1 FROM graphql_schemas newer
+    WHERE newer.collection_id = s.collection_id
+      AND (newer.fetched_at, newer.url) > (s.fetched_at, s.url)
+);
+ALTER TABLE graphql_schemas DROP CONSTRAINT graphql_schemas_pkey;
+ALTER TABLE graphql_schemas ADD PRIMARY KEY (collection_id);
+-- +goose StatementEnd
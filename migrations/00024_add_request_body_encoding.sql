@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE executions ADD COLUMN request_body_encoding VARCHAR(20);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE executions DROP COLUMN IF EXISTS request_body_encoding;
+-- +goose StatementEnd